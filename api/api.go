@@ -3,5 +3,5 @@ package api
 // Constants for client.
 const (
   // DefaultVerions of Current REST API
-  DefaultVersion string = "1.0"
+  DefaultVersion string = "1.1"
 )
@@ -0,0 +1,55 @@
+// Package errdefs defines the classes of errors returned by the Providence
+// server and the client. Errors are classified by the interfaces they
+// implement rather than by their concrete type, so any error can join a
+// class by implementing the matching method.
+package errdefs
+
+// ErrNotFound signals that the requested object doesn't exist.
+type ErrNotFound interface {
+  NotFound() bool
+}
+
+// ErrInvalidParameter signals that the user input is invalid.
+type ErrInvalidParameter interface {
+  InvalidParameter() bool
+}
+
+// ErrConflict signals that some internal state conflicts with the requested
+// action and can't be performed. A change in state should be able to clear
+// this error.
+type ErrConflict interface {
+  Conflict() bool
+}
+
+// ErrUnauthorized signals that the request lacks valid credentials.
+type ErrUnauthorized interface {
+  Unauthorized() bool
+}
+
+// ErrForbidden signals that the requested action cannot be performed under
+// any circumstances.
+type ErrForbidden interface {
+  Forbidden() bool
+}
+
+// ErrUnavailable signals that the requested action or resource is
+// temporarily unavailable.
+type ErrUnavailable interface {
+  Unavailable() bool
+}
+
+// ErrNotImplemented signals that the requested action or feature is not
+// implemented by the server.
+type ErrNotImplemented interface {
+  NotImplemented() bool
+}
+
+// ErrSystem signals that some internal error occurred on the server.
+type ErrSystem interface {
+  System() bool
+}
+
+// ErrUnknown signals that the kind of error that occurred is not known.
+type ErrUnknown interface {
+  Unknown() bool
+}
@@ -0,0 +1,145 @@
+package errdefs
+
+type errNotFound struct{ error }
+
+func (errNotFound) NotFound() bool { return true }
+
+func (e errNotFound) Cause() error {
+  return e.error
+}
+
+// NotFound is a helper to create an error of the class with the same name from any error type
+func NotFound(err error) error {
+  if err == nil || IsNotFound(err) {
+    return err
+  }
+  return errNotFound{err}
+}
+
+type errInvalidParameter struct{ error }
+
+func (errInvalidParameter) InvalidParameter() bool { return true }
+
+func (e errInvalidParameter) Cause() error {
+  return e.error
+}
+
+// InvalidParameter is a helper to create an error of the class with the same name from any error type
+func InvalidParameter(err error) error {
+  if err == nil || IsInvalidParameter(err) {
+    return err
+  }
+  return errInvalidParameter{err}
+}
+
+type errConflict struct{ error }
+
+func (errConflict) Conflict() bool { return true }
+
+func (e errConflict) Cause() error {
+  return e.error
+}
+
+// Conflict is a helper to create an error of the class with the same name from any error type
+func Conflict(err error) error {
+  if err == nil || IsConflict(err) {
+    return err
+  }
+  return errConflict{err}
+}
+
+type errUnauthorized struct{ error }
+
+func (errUnauthorized) Unauthorized() bool { return true }
+
+func (e errUnauthorized) Cause() error {
+  return e.error
+}
+
+// Unauthorized is a helper to create an error of the class with the same name from any error type
+func Unauthorized(err error) error {
+  if err == nil || IsUnauthorized(err) {
+    return err
+  }
+  return errUnauthorized{err}
+}
+
+type errForbidden struct{ error }
+
+func (errForbidden) Forbidden() bool { return true }
+
+func (e errForbidden) Cause() error {
+  return e.error
+}
+
+// Forbidden is a helper to create an error of the class with the same name from any error type
+func Forbidden(err error) error {
+  if err == nil || IsForbidden(err) {
+    return err
+  }
+  return errForbidden{err}
+}
+
+type errUnavailable struct{ error }
+
+func (errUnavailable) Unavailable() bool { return true }
+
+func (e errUnavailable) Cause() error {
+  return e.error
+}
+
+// Unavailable is a helper to create an error of the class with the same name from any error type
+func Unavailable(err error) error {
+  if err == nil || IsUnavailable(err) {
+    return err
+  }
+  return errUnavailable{err}
+}
+
+type errNotImplemented struct{ error }
+
+func (errNotImplemented) NotImplemented() bool { return true }
+
+func (e errNotImplemented) Cause() error {
+  return e.error
+}
+
+// NotImplemented is a helper to create an error of the class with the same name from any error type
+func NotImplemented(err error) error {
+  if err == nil || IsNotImplemented(err) {
+    return err
+  }
+  return errNotImplemented{err}
+}
+
+type errSystem struct{ error }
+
+func (errSystem) System() bool { return true }
+
+func (e errSystem) Cause() error {
+  return e.error
+}
+
+// System is a helper to create an error of the class with the same name from any error type
+func System(err error) error {
+  if err == nil || IsSystem(err) {
+    return err
+  }
+  return errSystem{err}
+}
+
+type errUnknown struct{ error }
+
+func (errUnknown) Unknown() bool { return true }
+
+func (e errUnknown) Cause() error {
+  return e.error
+}
+
+// Unknown is a helper to create an error of the class with the same name from any error type
+func Unknown(err error) error {
+  if err == nil || IsUnknown(err) {
+    return err
+  }
+  return errUnknown{err}
+}
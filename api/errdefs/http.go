@@ -0,0 +1,37 @@
+package errdefs
+
+import (
+  "net/http"
+)
+
+// FromStatusCode wraps err in the class of error matching the given HTTP
+// status code. Errors for successful status codes are returned unchanged.
+func FromStatusCode(err error, statusCode int) error {
+  if err == nil {
+    return err
+  }
+  switch statusCode {
+  case http.StatusBadRequest:
+    err = InvalidParameter(err)
+  case http.StatusUnauthorized:
+    err = Unauthorized(err)
+  case http.StatusForbidden:
+    err = Forbidden(err)
+  case http.StatusNotFound:
+    err = NotFound(err)
+  case http.StatusConflict:
+    err = Conflict(err)
+  case http.StatusNotImplemented:
+    err = NotImplemented(err)
+  case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+    err = Unavailable(err)
+  default:
+    switch {
+    case statusCode >= 500:
+      err = System(err)
+    case statusCode >= 400:
+      err = Unknown(err)
+    }
+  }
+  return err
+}
@@ -0,0 +1,61 @@
+package errdefs
+
+import (
+  "errors"
+  "net/http"
+  "testing"
+)
+
+func TestFromStatusCode(t *testing.T) {
+  cases := []struct {
+    statusCode int
+    check      func(error) bool
+  }{
+    {http.StatusBadRequest, IsInvalidParameter},
+    {http.StatusUnauthorized, IsUnauthorized},
+    {http.StatusForbidden, IsForbidden},
+    {http.StatusNotFound, IsNotFound},
+    {http.StatusConflict, IsConflict},
+    {http.StatusNotImplemented, IsNotImplemented},
+    {http.StatusTooManyRequests, IsUnavailable},
+    {http.StatusServiceUnavailable, IsUnavailable},
+    {http.StatusInternalServerError, IsSystem},
+    {http.StatusTeapot, IsUnknown},
+  }
+
+  for _, c := range cases {
+    err := FromStatusCode(errors.New("some error"), c.statusCode)
+    if !c.check(err) {
+      t.Fatalf("unexpected class for status code %d: %T", c.statusCode, err)
+    }
+    if err.Error() != "some error" {
+      t.Fatalf("expected the message to be preserved, got %q", err.Error())
+    }
+  }
+
+  if err := FromStatusCode(nil, http.StatusNotFound); err != nil {
+    t.Fatalf("expected nil, got %v", err)
+  }
+}
+
+type causeError struct {
+  cause error
+}
+
+func (e causeError) Error() string {
+  return "wrapped: " + e.cause.Error()
+}
+
+func (e causeError) Cause() error {
+  return e.cause
+}
+
+func TestIsFollowsCauses(t *testing.T) {
+  err := causeError{NotFound(errors.New("no such engine"))}
+  if !IsNotFound(err) {
+    t.Fatal("expected a wrapped not found error to be detected")
+  }
+  if IsConflict(err) {
+    t.Fatal("expected a not found error not to be a conflict")
+  }
+}
@@ -0,0 +1,79 @@
+package errdefs
+
+type causer interface {
+  Cause() error
+}
+
+func getImplementer(err error) error {
+  switch e := err.(type) {
+  case
+    ErrNotFound,
+    ErrInvalidParameter,
+    ErrConflict,
+    ErrUnauthorized,
+    ErrForbidden,
+    ErrUnavailable,
+    ErrNotImplemented,
+    ErrSystem,
+    ErrUnknown:
+    return err
+  case causer:
+    return getImplementer(e.Cause())
+  default:
+    return err
+  }
+}
+
+// IsNotFound returns if the passed in error is an ErrNotFound
+func IsNotFound(err error) bool {
+  e, ok := getImplementer(err).(ErrNotFound)
+  return ok && e.NotFound()
+}
+
+// IsInvalidParameter returns if the passed in error is an ErrInvalidParameter
+func IsInvalidParameter(err error) bool {
+  e, ok := getImplementer(err).(ErrInvalidParameter)
+  return ok && e.InvalidParameter()
+}
+
+// IsConflict returns if the passed in error is an ErrConflict
+func IsConflict(err error) bool {
+  e, ok := getImplementer(err).(ErrConflict)
+  return ok && e.Conflict()
+}
+
+// IsUnauthorized returns if the passed in error is an ErrUnauthorized
+func IsUnauthorized(err error) bool {
+  e, ok := getImplementer(err).(ErrUnauthorized)
+  return ok && e.Unauthorized()
+}
+
+// IsForbidden returns if the passed in error is an ErrForbidden
+func IsForbidden(err error) bool {
+  e, ok := getImplementer(err).(ErrForbidden)
+  return ok && e.Forbidden()
+}
+
+// IsUnavailable returns if the passed in error is an ErrUnavailable
+func IsUnavailable(err error) bool {
+  e, ok := getImplementer(err).(ErrUnavailable)
+  return ok && e.Unavailable()
+}
+
+// IsNotImplemented returns if the passed in error is an ErrNotImplemented
+func IsNotImplemented(err error) bool {
+  e, ok := getImplementer(err).(ErrNotImplemented)
+  return ok && e.NotImplemented()
+}
+
+// IsSystem returns if the passed in error is an ErrSystem
+func IsSystem(err error) bool {
+  e, ok := getImplementer(err).(ErrSystem)
+  return ok && e.System()
+}
+
+// IsUnknown returns if the passed in error is an ErrUnknown
+func IsUnknown(err error) bool {
+  e, ok := getImplementer(err).(ErrUnknown)
+  return ok && e.Unknown()
+}
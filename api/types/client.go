@@ -2,12 +2,24 @@ package types
 
 import (
   "io"
+
+  "github.com/TopPano/providence-cli/api/types/filters"
 )
 
 // EngineBuildOptions holds the information
 // necessary to build engines.
 type EngineBuildOptions struct {
+  Tags        []string
   Enginefile  string
+  // BuildArgs holds the build-time variables. A nil value means the
+  // variable was named without a value and the Enginefile default applies.
+  BuildArgs   map[string]*string
+  // SessionID is the context session the build context was synchronized
+  // with. The build context is then a manifest and the missing files.
+  SessionID   string
+  // ContextDigest references a build context held by the server, which
+  // is built instead of an uploaded one.
+  ContextDigest string
 }
 
 // EngineBuildResponse holds information
@@ -16,3 +28,14 @@ type EngineBuildOptions struct {
 type EngineBuildResponse struct {
   Body    io.ReadCloser
 }
+
+// EngineListOptions holds parameters to filter the list of engines with.
+type EngineListOptions struct {
+  Filters filters.Args
+}
+
+// EngineRemoveOptions holds parameters to remove engines.
+type EngineRemoveOptions struct {
+  Force         bool
+  PruneChildren bool
+}
@@ -0,0 +1,151 @@
+// Package filters provides helper function to parse and handle command line
+// filter, used for example in engine listing.
+package filters
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "strings"
+)
+
+// Args stores filter arguments as map key:{map key: bool}.
+// It contains an aggregation of the map of arguments (which are in the form
+// of -f 'key=value') based on the key, and stores values for the same key
+// in a map with string keys and boolean values.
+// e.g given -f 'label=label1=1' -f 'label=label2=2' -f 'name=prov'
+// the args will be {"name":{"prov":true},"label":{"label1=1":true,"label2=2":true}}
+type Args struct {
+  fields map[string]map[string]bool
+}
+
+// NewArgs initializes a new Args struct.
+func NewArgs() Args {
+  return Args{fields: map[string]map[string]bool{}}
+}
+
+// ErrBadFormat is an error returned in case of bad format for a filter.
+var ErrBadFormat = errors.New("bad format of filter (expected name=value)")
+
+// ParseFlag parses the argument to the filter flag. Like
+//
+//   `prov engine ls -f 'label=foo' -f 'name=bar'`
+//
+// If prev map is provided, then it is appended to, and returned. By default a new
+// map is created.
+func ParseFlag(arg string, prev Args) (Args, error) {
+  filters := prev
+  if len(arg) == 0 {
+    return filters, nil
+  }
+
+  if !strings.Contains(arg, "=") {
+    return filters, ErrBadFormat
+  }
+
+  f := strings.SplitN(arg, "=", 2)
+
+  name := strings.ToLower(strings.TrimSpace(f[0]))
+  value := strings.TrimSpace(f[1])
+
+  filters.Add(name, value)
+
+  return filters, nil
+}
+
+// ToParam packs the Args into a string for easy transport from client to server.
+func ToParam(a Args) (string, error) {
+  // this way we don't URL encode {}, just empty space
+  if a.Len() == 0 {
+    return "", nil
+  }
+
+  buf, err := json.Marshal(a.fields)
+  if err != nil {
+    return "", err
+  }
+  return string(buf), nil
+}
+
+// FromParam unpacks the filter Args.
+func FromParam(p string) (Args, error) {
+  if len(p) == 0 {
+    return NewArgs(), nil
+  }
+
+  r := strings.NewReader(p)
+  d := json.NewDecoder(r)
+
+  m := map[string]map[string]bool{}
+  if err := d.Decode(&m); err != nil {
+    return NewArgs(), err
+  }
+  return Args{fields: m}, nil
+}
+
+// Get returns the list of values associates with a field.
+// It returns a slice of strings to keep backwards compatibility with old code.
+func (filters Args) Get(field string) []string {
+  values := filters.fields[field]
+  if values == nil {
+    return make([]string, 0)
+  }
+  slice := make([]string, 0, len(values))
+  for key := range values {
+    slice = append(slice, key)
+  }
+  return slice
+}
+
+// Add adds a new value to a filter field.
+func (filters Args) Add(name, value string) {
+  if _, ok := filters.fields[name]; ok {
+    filters.fields[name][value] = true
+  } else {
+    filters.fields[name] = map[string]bool{value: true}
+  }
+}
+
+// Del removes a value from a filter field.
+func (filters Args) Del(name, value string) {
+  if _, ok := filters.fields[name]; ok {
+    delete(filters.fields[name], value)
+    if len(filters.fields[name]) == 0 {
+      delete(filters.fields, name)
+    }
+  }
+}
+
+// Len returns the number of fields in the arguments.
+func (filters Args) Len() int {
+  return len(filters.fields)
+}
+
+// Include returns true if the name of the field to filter is in the filters.
+func (filters Args) Include(field string) bool {
+  _, ok := filters.fields[field]
+  return ok
+}
+
+// ExactMatch returns true if the source matches exactly one of the filters.
+func (filters Args) ExactMatch(field, source string) bool {
+  fieldValues, ok := filters.fields[field]
+  //do not filter if there is no filter set or cannot determine filter
+  if !ok || len(fieldValues) == 0 {
+    return true
+  }
+
+  // try to match full name value to avoid O(N) regular expression matching
+  return fieldValues[source]
+}
+
+// Validate ensures that all the fields in the filter are valid.
+// It returns an error as soon as it finds an invalid field.
+func (filters Args) Validate(accepted map[string]bool) error {
+  for name := range filters.fields {
+    if !accepted[name] {
+      return fmt.Errorf("Invalid filter '%s'", name)
+    }
+  }
+  return nil
+}
@@ -0,0 +1,70 @@
+package filters
+
+import (
+  "testing"
+)
+
+func TestParseFlag(t *testing.T) {
+  args := NewArgs()
+  var err error
+  for _, arg := range []string{"label=a=b", "label=c", "name= prov ", "dangling=true"} {
+    args, err = ParseFlag(arg, args)
+    if err != nil {
+      t.Fatalf("Unexpected error parsing %q: %v", arg, err)
+    }
+  }
+
+  if args.Len() != 3 {
+    t.Fatalf("Expected 3 filter fields, got %d", args.Len())
+  }
+  if len(args.Get("label")) != 2 {
+    t.Fatalf("Expected 2 label values, got %v", args.Get("label"))
+  }
+  if !args.ExactMatch("name", "prov") {
+    t.Fatalf("Expected name filter to be trimmed, got %v", args.Get("name"))
+  }
+
+  if _, err := ParseFlag("dangling", args); err != ErrBadFormat {
+    t.Fatalf("Expected ErrBadFormat, got %v", err)
+  }
+}
+
+func TestToParamFromParam(t *testing.T) {
+  empty, err := ToParam(NewArgs())
+  if err != nil {
+    t.Fatal(err)
+  }
+  if empty != "" {
+    t.Fatalf("Expected empty param for empty args, got %q", empty)
+  }
+
+  args := NewArgs()
+  args.Add("label", "foo=bar")
+  args.Add("before", "abc")
+
+  param, err := ToParam(args)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  decoded, err := FromParam(param)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if decoded.Len() != 2 || !decoded.ExactMatch("label", "foo=bar") || !decoded.ExactMatch("before", "abc") {
+    t.Fatalf("Round trip mismatch: %q decoded to %v", param, decoded.fields)
+  }
+}
+
+func TestValidate(t *testing.T) {
+  args := NewArgs()
+  args.Add("label", "foo")
+  if err := args.Validate(map[string]bool{"label": true}); err != nil {
+    t.Fatalf("Expected label to be valid, got %v", err)
+  }
+
+  args.Add("color", "blue")
+  if err := args.Validate(map[string]bool{"label": true}); err == nil {
+    t.Fatal("Expected an error for an unknown filter")
+  }
+}
@@ -0,0 +1,152 @@
+package types
+
+import (
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "os"
+)
+
+// EngineSummary holds the summary information about an engine
+// returned by the server when listing engines.
+type EngineSummary struct {
+  // ID is the content-addressable ID of the engine.
+  ID string `json:"Id"`
+  // ParentID is the ID of the engine this one was built from, if any.
+  ParentID string `json:"ParentId"`
+  // RepoTags is the list of names referencing this engine.
+  RepoTags []string
+  // Created is the Unix timestamp at which the engine was built.
+  Created int64
+  // Size is the total size of the engine in bytes.
+  Size int64
+  // Labels holds the user-defined metadata of the engine.
+  Labels map[string]string
+}
+
+// EngineInspect holds the full metadata of an engine
+// returned by the server when inspecting it.
+type EngineInspect struct {
+  // ID is the content-addressable ID of the engine.
+  ID string `json:"Id"`
+  // ParentID is the ID of the engine this one was built from, if any.
+  ParentID string `json:"ParentId"`
+  // RepoTags is the list of names referencing this engine.
+  RepoTags []string
+  // Labels holds the user-defined metadata of the engine.
+  Labels map[string]string
+  // Enginefile is the path of the Enginefile inside the build context.
+  Enginefile string
+  // EnginefileDigest is the digest of the Enginefile the engine was built from.
+  EnginefileDigest string
+  // BuildArgs holds the build-time variables the engine was built with.
+  BuildArgs map[string]*string
+  // Created is the time the engine was built, formatted as RFC 3339.
+  Created string
+  // Size is the total size of the engine in bytes.
+  Size int64
+}
+
+// EngineDeleteResponseItem reports an engine name that was untagged
+// or an engine ID that was deleted by a remove request.
+type EngineDeleteResponseItem struct {
+  // Untagged is the name that was removed from the engine.
+  Untagged string `json:",omitempty"`
+  // Deleted is the ID of the engine that was deleted.
+  Deleted string `json:",omitempty"`
+}
+
+// BuildResult contains the auxiliary information reported by the server
+// at the end of an engine build.
+type BuildResult struct {
+  // ID is the ID of the built engine.
+  ID string
+  // UnusedBuildArgs lists the build args that were not consumed
+  // by the Enginefile.
+  UnusedBuildArgs []string `json:",omitempty"`
+}
+
+// ContextSessionRequest lists the content hashes of the files of a build
+// context, to find out which of them the server already holds.
+type ContextSessionRequest struct {
+  Hashes []string
+}
+
+// ContextSessionResponse is returned when a context session is opened.
+type ContextSessionResponse struct {
+  // ID identifies the session in the build request.
+  ID string
+  // Missing lists the hashes of the files that must be uploaded.
+  Missing []string
+}
+
+// Layout of the build context uploaded for a context session: a tar stream
+// starting with the manifest, followed by the missing files named after
+// their hash, such as "blobs/sha256/<hex>".
+const (
+  ContextManifestPath = "manifest.json"
+  ContextBlobsDir     = "blobs"
+)
+
+// ContextManifest describes the files of a build context uploaded through
+// a context session. It also identifies build contexts held by the server.
+type ContextManifest struct {
+  Entries []ContextManifestEntry
+}
+
+// Digest returns the digest identifying the build context described by the
+// manifest, in the form "sha256:<hex>": the hash of its JSON encoding. The
+// digest of an uploaded tar stream is the one of the manifest listing its
+// entries in order, with the mode of their header and the path of
+// directories without a trailing slash.
+func (m ContextManifest) Digest() (string, error) {
+  content, err := json.Marshal(m)
+  if err != nil {
+    return "", err
+  }
+  sum := sha256.Sum256(content)
+  return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// ContextManifestEntry describes a file of a build context. Regular files
+// have a Hash, symbolic links a Linkname.
+type ContextManifestEntry struct {
+  // Path is the slash separated path of the file in the build context.
+  Path     string
+  Mode     os.FileMode
+  Size     int64  `json:",omitempty"`
+  Hash     string `json:",omitempty"`
+  Linkname string `json:",omitempty"`
+}
+
+// Version contains the version information of a Providence component.
+type Version struct {
+  Version       string
+  APIVersion    string `json:"ApiVersion"`
+  MinAPIVersion string `json:",omitempty"`
+  GitCommit     string
+  GoVersion     string
+  Os            string
+  Arch          string
+  BuildTime     string `json:",omitempty"`
+}
+
+// VersionResponse holds version information for the client and the server
+type VersionResponse struct {
+  Client *Version
+  Server *Version
+}
+
+// ServerOK returns true when the client could connect to the server
+// and parse the version information.
+func (v VersionResponse) ServerOK() bool {
+  return v.Server != nil
+}
+
+// Ping contains the response of the server's ping endpoint.
+type Ping struct {
+  // APIVersion is the most recent API version supported by the server.
+  APIVersion string
+  // MinAPIVersion is the oldest API version supported by the server.
+  MinAPIVersion string
+}
@@ -0,0 +1,39 @@
+package versions
+
+import (
+  "testing"
+)
+
+func assertVersion(t *testing.T, a, b string, result int) {
+  if r := compare(a, b); r != result {
+    t.Fatalf("Unexpected version comparison result. Found %d, expected %d", r, result)
+  }
+}
+
+func TestCompareVersion(t *testing.T) {
+  assertVersion(t, "1.12", "1.12", 0)
+  assertVersion(t, "1.0.0", "1", 0)
+  assertVersion(t, "1", "1.0.0", 0)
+  assertVersion(t, "1.05.00.0156", "1.0.221.9289", 1)
+  assertVersion(t, "1", "1.0.1", -1)
+  assertVersion(t, "1.0.1", "1", 1)
+  assertVersion(t, "1.0.1", "1.0.2", -1)
+  assertVersion(t, "1.0.2", "1.0.3", -1)
+  assertVersion(t, "1.0.3", "1.1", -1)
+  assertVersion(t, "1.1", "1.1.1", -1)
+  assertVersion(t, "1.1.1", "1.1.2", -1)
+  assertVersion(t, "1.1.2", "1.2", -1)
+  assertVersion(t, "1.10", "1.9", 1)
+}
+
+func TestLessThan(t *testing.T) {
+  if !LessThan("1.0", "1.1") {
+    t.Fatal("expected 1.0 to be less than 1.1")
+  }
+  if LessThan("1.1", "1.1") {
+    t.Fatal("expected 1.1 not to be less than 1.1")
+  }
+  if LessThan("1.1", "") {
+    t.Fatal("expected 1.1 not to be less than an empty version")
+  }
+}
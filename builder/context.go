@@ -13,7 +13,6 @@ import (
 
   "github.com/docker/docker/pkg/archive"
   "github.com/docker/docker/pkg/fileutils"
-  "github.com/docker/docker/pkg/gitutils"
   "github.com/docker/docker/pkg/httputils"
   "github.com/docker/docker/pkg/ioutils"
   "github.com/docker/docker/pkg/progress"
@@ -68,6 +67,95 @@ func ValidateContextDirectory(srcPath string, excludes []string) error {
   })
 }
 
+// ContextSize returns the number and total size of the regular files of the
+// context directory found under includes and not matched by excludes, that
+// is the files sent to the server.
+func ContextSize(contextDir string, includes, excludes []string) (files int, size int64, err error) {
+  err = walkContext(contextDir, includes, excludes, func(relFilePath string, f os.FileInfo) error {
+    if f.Mode().IsRegular() {
+      files++
+      size += f.Size()
+    }
+    return nil
+  })
+  return files, size, err
+}
+
+// walkContext calls walkFn for every file and directory of the context
+// directory which is sent to the server given the includes and excludes,
+// with its slash separated path relative to the context directory.
+func walkContext(contextDir string, includes, excludes []string, walkFn func(relFilePath string, f os.FileInfo) error) error {
+  // Excluded directories can only be skipped when no pattern re-includes
+  // some of their files
+  _, _, exceptions, err := fileutils.CleanPatterns(excludes)
+  if err != nil {
+    return err
+  }
+
+  return filepath.Walk(contextDir, func(filePath string, f os.FileInfo, err error) error {
+    if err != nil {
+      return err
+    }
+    relFilePath, err := filepath.Rel(contextDir, filePath)
+    if err != nil {
+      return err
+    }
+    if relFilePath == "." {
+      return nil
+    }
+    relFilePath = filepath.ToSlash(relFilePath)
+
+    if !isIncluded(relFilePath, includes) {
+      if f.IsDir() && !isIncludeParent(relFilePath, includes) {
+        return filepath.SkipDir
+      }
+      return nil
+    }
+    // Like archive.TarWithOptions, an excluded file is only sent when it
+    // is explicitly included
+    if skip, err := fileutils.Matches(relFilePath, excludes); err != nil {
+      return err
+    } else if skip && !isIncludeRoot(relFilePath, includes) {
+      if f.IsDir() && !exceptions {
+        return filepath.SkipDir
+      }
+      return nil
+    }
+    return walkFn(relFilePath, f)
+  })
+}
+
+// isIncludeParent returns true if one of includes is inside the directory path.
+func isIncludeParent(path string, includes []string) bool {
+  for _, include := range includes {
+    if strings.HasPrefix(filepath.ToSlash(filepath.Clean(include)), path+"/") {
+      return true
+    }
+  }
+  return false
+}
+
+// isIncludeRoot returns true if path is one of includes.
+func isIncludeRoot(path string, includes []string) bool {
+  for _, include := range includes {
+    if path == filepath.ToSlash(filepath.Clean(include)) {
+      return true
+    }
+  }
+  return false
+}
+
+// isIncluded returns true if path is one of includes or inside one of them.
+func isIncluded(path string, includes []string) bool {
+  for _, include := range includes {
+    include = filepath.ToSlash(filepath.Clean(include))
+    if include == "." || path == include || strings.HasPrefix(path, include+"/") {
+      return true
+    }
+  }
+  return false
+}
+
 // GetContextFromReader will read the contents of the given reader as either a
 // Enginefile or tar archive. Returns a tar archive used as a context and a
 // path to the Enginefile inside the tar.
@@ -120,19 +208,26 @@ func GetContextFromReader(r io.ReadCloser, enginefileName string) (out io.ReadCl
 }
 
 // GetContextFromGitURL uses a Git URL as context for a `prov engine build`. The
-// git repo is cloned into a temporary directory used as the context directory.
-// Returns the absolute path to the temporary context directory, the relative
-// path of the enginefile in that context directory, and a non-nil error on
-// success.
-func GetContextFromGitURL(gitURL, enginefileName string) (absContextDir, relEnginefile string, err error) {
+// git repo is cloned into a temporary directory, in which the context
+// directory is selected by the optional `#ref:subdir` fragment of the URL.
+// Returns the temporary directory of the clone, which the caller must
+// remove, the absolute path to the context directory, the relative path of
+// the enginefile in that context directory, and a non-nil error on
+// success. The clone is removed on error.
+func GetContextFromGitURL(gitURL, enginefileName string) (cloneDir, absContextDir, relEnginefile string, err error) {
   if _, err := exec.LookPath("git"); err != nil {
-    return "", "", fmt.Errorf("unable to find 'git': %v", err)
+    return "", "", "", fmt.Errorf("unable to find 'git': %v", err)
   }
-  if absContextDir, err = gitutils.Clone(gitURL); err != nil {
-    return "", "", fmt.Errorf("unable to 'git clone' to temporary context directory: %v", err)
+  cloneDir, absContextDir, err = cloneGitRepository(gitURL)
+  if err != nil {
+    return "", "", "", fmt.Errorf("unable to 'git clone' to temporary context directory: %v", err)
   }
 
-  return getEnginefileRelPath(absContextDir, enginefileName)
+  if absContextDir, relEnginefile, err = getEnginefileRelPath(absContextDir, enginefileName); err != nil {
+    os.RemoveAll(cloneDir)
+    return "", "", "", err
+  }
+  return cloneDir, absContextDir, relEnginefile, nil
 }
 
 // GetContextFromURL uses a remote URL as context for a `prov engine build`. The
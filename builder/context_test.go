@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -23,7 +25,7 @@ var prepareNoFiles = func(t *testing.T) (string, func()) {
 
 var prepareOneFile = func(t *testing.T) (string, func()) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 	return contextDir, cleanup
 }
 
@@ -38,11 +40,11 @@ func testValidateContextDirectory(t *testing.T, prepare func(t *testing.T) (stri
 	}
 }
 
-func TestGetContextFromLocalDirNoDockerfile(t *testing.T) {
+func TestGetContextFromLocalDirNoEnginefile(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(contextDir, "")
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(contextDir, "")
 
 	if err == nil {
 		t.Fatalf("Error should not be nil")
@@ -52,8 +54,8 @@ func TestGetContextFromLocalDirNoDockerfile(t *testing.T) {
 		t.Fatalf("Absolute directory path should be empty, got: %s", absContextDir)
 	}
 
-	if relDockerfile != "" {
-		t.Fatalf("Relative path to Dockerfile should be empty, got: %s", relDockerfile)
+	if relEnginefile != "" {
+		t.Fatalf("Relative path to Enginefile should be empty, got: %s", relEnginefile)
 	}
 }
 
@@ -63,7 +65,7 @@ func TestGetContextFromLocalDirNotExistingDir(t *testing.T) {
 
 	fakePath := filepath.Join(contextDir, "fake")
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(fakePath, "")
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(fakePath, "")
 
 	if err == nil {
 		t.Fatalf("Error should not be nil")
@@ -73,18 +75,18 @@ func TestGetContextFromLocalDirNotExistingDir(t *testing.T) {
 		t.Fatalf("Absolute directory path should be empty, got: %s", absContextDir)
 	}
 
-	if relDockerfile != "" {
-		t.Fatalf("Relative path to Dockerfile should be empty, got: %s", relDockerfile)
+	if relEnginefile != "" {
+		t.Fatalf("Relative path to Enginefile should be empty, got: %s", relEnginefile)
 	}
 }
 
-func TestGetContextFromLocalDirNotExistingDockerfile(t *testing.T) {
+func TestGetContextFromLocalDirNotExistingEnginefile(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
 	fakePath := filepath.Join(contextDir, "fake")
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(contextDir, fakePath)
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(contextDir, fakePath)
 
 	if err == nil {
 		t.Fatalf("Error should not be nil")
@@ -94,8 +96,8 @@ func TestGetContextFromLocalDirNotExistingDockerfile(t *testing.T) {
 		t.Fatalf("Absolute directory path should be empty, got: %s", absContextDir)
 	}
 
-	if relDockerfile != "" {
-		t.Fatalf("Relative path to Dockerfile should be empty, got: %s", relDockerfile)
+	if relEnginefile != "" {
+		t.Fatalf("Relative path to Enginefile should be empty, got: %s", relEnginefile)
 	}
 }
 
@@ -103,12 +105,12 @@ func TestGetContextFromLocalDirWithNoDirectory(t *testing.T) {
 	contextDir, dirCleanup := createTestTempDir(t, "", "builder-context-test")
 	defer dirCleanup()
 
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 
 	chdirCleanup := chdir(t, contextDir)
 	defer chdirCleanup()
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(contextDir, "")
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(contextDir, "")
 
 	if err != nil {
 		t.Fatalf("Error when getting context from local dir: %s", err)
@@ -118,18 +120,18 @@ func TestGetContextFromLocalDirWithNoDirectory(t *testing.T) {
 		t.Fatalf("Absolute directory path should be equal to %s, got: %s", contextDir, absContextDir)
 	}
 
-	if relDockerfile != DefaultDockerfileName {
-		t.Fatalf("Relative path to dockerfile should be equal to %s, got: %s", DefaultDockerfileName, relDockerfile)
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path to Enginefile should be equal to %s, got: %s", DefaultEnginefileName, relEnginefile)
 	}
 }
 
-func TestGetContextFromLocalDirWithDockerfile(t *testing.T) {
+func TestGetContextFromLocalDirWithEnginefile(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(contextDir, "")
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(contextDir, "")
 
 	if err != nil {
 		t.Fatalf("Error when getting context from local dir: %s", err)
@@ -139,8 +141,8 @@ func TestGetContextFromLocalDirWithDockerfile(t *testing.T) {
 		t.Fatalf("Absolute directory path should be equal to %s, got: %s", contextDir, absContextDir)
 	}
 
-	if relDockerfile != DefaultDockerfileName {
-		t.Fatalf("Relative path to dockerfile should be equal to %s, got: %s", DefaultDockerfileName, relDockerfile)
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path to Enginefile should be equal to %s, got: %s", DefaultEnginefileName, relEnginefile)
 	}
 }
 
@@ -148,10 +150,10 @@ func TestGetContextFromLocalDirLocalFile(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 	testFilename := createTestTempFile(t, contextDir, "tmpTest", "test", 0777)
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(testFilename, "")
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(testFilename, "")
 
 	if err == nil {
 		t.Fatalf("Error should not be nil")
@@ -161,21 +163,21 @@ func TestGetContextFromLocalDirLocalFile(t *testing.T) {
 		t.Fatalf("Absolute directory path should be empty, got: %s", absContextDir)
 	}
 
-	if relDockerfile != "" {
-		t.Fatalf("Relative path to Dockerfile should be empty, got: %s", relDockerfile)
+	if relEnginefile != "" {
+		t.Fatalf("Relative path to Enginefile should be empty, got: %s", relEnginefile)
 	}
 }
 
-func TestGetContextFromLocalDirWithCustomDockerfile(t *testing.T) {
+func TestGetContextFromLocalDirWithCustomEnginefile(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
 	chdirCleanup := chdir(t, contextDir)
 	defer chdirCleanup()
 
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 
-	absContextDir, relDockerfile, err := GetContextFromLocalDir(contextDir, DefaultDockerfileName)
+	absContextDir, relEnginefile, err := GetContextFromLocalDir(contextDir, DefaultEnginefileName)
 
 	if err != nil {
 		t.Fatalf("Error when getting context from local dir: %s", err)
@@ -185,14 +187,14 @@ func TestGetContextFromLocalDirWithCustomDockerfile(t *testing.T) {
 		t.Fatalf("Absolute directory path should be equal to %s, got: %s", contextDir, absContextDir)
 	}
 
-	if relDockerfile != DefaultDockerfileName {
-		t.Fatalf("Relative path to dockerfile should be equal to %s, got: %s", DefaultDockerfileName, relDockerfile)
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path to Enginefile should be equal to %s, got: %s", DefaultEnginefileName, relEnginefile)
 	}
 
 }
 
 func TestGetContextFromReaderString(t *testing.T) {
-	tarArchive, relDockerfile, err := GetContextFromReader(ioutil.NopCloser(strings.NewReader(dockerfileContents)), "")
+	tarArchive, relEnginefile, err := GetContextFromReader(ioutil.NopCloser(strings.NewReader(enginefileContents)), "")
 
 	if err != nil {
 		t.Fatalf("Error when executing GetContextFromReader: %s", err)
@@ -220,12 +222,55 @@ func TestGetContextFromReaderString(t *testing.T) {
 		t.Fatalf("Error when closing tar stream: %s", err)
 	}
 
-	if dockerfileContents != contents {
-		t.Fatalf("Uncompressed tar archive does not equal: %s, got: %s", dockerfileContents, contents)
+	if enginefileContents != contents {
+		t.Fatalf("Uncompressed tar archive does not equal: %s, got: %s", enginefileContents, contents)
 	}
 
-	if relDockerfile != DefaultDockerfileName {
-		t.Fatalf("Relative path not equals %s, got: %s", DefaultDockerfileName, relDockerfile)
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path not equals %s, got: %s", DefaultEnginefileName, relEnginefile)
+	}
+}
+
+func TestGetContextFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(enginefileContents))
+	}))
+	defer server.Close()
+
+	out := new(bytes.Buffer)
+	tarArchive, relEnginefile, err := GetContextFromURL(out, server.URL+"/Enginefile", "")
+	if err != nil {
+		t.Fatalf("Error when executing GetContextFromURL: %s", err)
+	}
+	defer tarArchive.Close()
+
+	tarReader := tar.NewReader(tarArchive)
+	hdr, err := tarReader.Next()
+	if err != nil {
+		t.Fatalf("Error when reading tar archive: %s", err)
+	}
+	contents, err := ioutil.ReadAll(tarReader)
+	if err != nil {
+		t.Fatalf("Error when reading tar archive: %s", err)
+	}
+
+	if hdr.Name != DefaultEnginefileName || string(contents) != enginefileContents {
+		t.Fatalf("Expected the downloaded Enginefile, got %s: %s", hdr.Name, contents)
+	}
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path not equals %s, got: %s", DefaultEnginefileName, relEnginefile)
+	}
+	if !strings.Contains(out.String(), "Downloading build context from remote url") {
+		t.Fatalf("Expected the download progress, got: %s", out.String())
+	}
+}
+
+func TestGetContextFromURLNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, _, err := GetContextFromURL(ioutil.Discard, server.URL+"/Enginefile", ""); err == nil {
+		t.Fatal("Expected an error when the remote context can't be downloaded")
 	}
 }
 
@@ -233,7 +278,7 @@ func TestGetContextFromReaderTar(t *testing.T) {
 	contextDir, cleanup := createTestTempDir(t, "", "builder-context-test")
 	defer cleanup()
 
-	createTestTempFile(t, contextDir, DefaultDockerfileName, dockerfileContents, 0777)
+	createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0777)
 
 	tarStream, err := archive.Tar(contextDir, archive.Uncompressed)
 
@@ -241,7 +286,7 @@ func TestGetContextFromReaderTar(t *testing.T) {
 		t.Fatalf("Error when creating tar: %s", err)
 	}
 
-	tarArchive, relDockerfile, err := GetContextFromReader(tarStream, DefaultDockerfileName)
+	tarArchive, relEnginefile, err := GetContextFromReader(tarStream, DefaultEnginefileName)
 
 	if err != nil {
 		t.Fatalf("Error when executing GetContextFromReader: %s", err)
@@ -255,8 +300,8 @@ func TestGetContextFromReaderTar(t *testing.T) {
 		t.Fatalf("Error when reading tar archive: %s", err)
 	}
 
-	if header.Name != DefaultDockerfileName {
-		t.Fatalf("Dockerfile name should be: %s, got: %s", DefaultDockerfileName, header.Name)
+	if header.Name != DefaultEnginefileName {
+		t.Fatalf("Enginefile name should be: %s, got: %s", DefaultEnginefileName, header.Name)
 	}
 
 	buff := new(bytes.Buffer)
@@ -273,12 +318,12 @@ func TestGetContextFromReaderTar(t *testing.T) {
 		t.Fatalf("Error when closing tar stream: %s", err)
 	}
 
-	if dockerfileContents != contents {
-		t.Fatalf("Uncompressed tar archive does not equal: %s, got: %s", dockerfileContents, contents)
+	if enginefileContents != contents {
+		t.Fatalf("Uncompressed tar archive does not equal: %s, got: %s", enginefileContents, contents)
 	}
 
-	if relDockerfile != DefaultDockerfileName {
-		t.Fatalf("Relative path not equals %s, got: %s", DefaultDockerfileName, relDockerfile)
+	if relEnginefile != DefaultEnginefileName {
+		t.Fatalf("Relative path not equals %s, got: %s", DefaultEnginefileName, relEnginefile)
 	}
 }
 
@@ -303,5 +348,5 @@ func TestValidateContextDirectoryWithOneFile(t *testing.T) {
 }
 
 func TestValidateContextDirectoryWithOneFileExcludes(t *testing.T) {
-	testValidateContextDirectory(t, prepareOneFile, []string{DefaultDockerfileName})
+	testValidateContextDirectory(t, prepareOneFile, []string{DefaultEnginefileName})
 }
@@ -0,0 +1,161 @@
+package builder
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+
+  "github.com/TopPano/providence-cli/builder/provignore"
+  "github.com/docker/docker/pkg/fileutils"
+  "github.com/docker/docker/pkg/symlink"
+)
+
+// dirContext is a Context backed by a local directory.
+type dirContext struct {
+  root       string
+  excludes   []string
+  exceptions bool
+}
+
+// NewDirectoryContext returns a Context for the directory root. Walk skips
+// the files excluded by the .provignore file of root.
+func NewDirectoryContext(root string) (ModifiableContext, error) {
+  return newDirContext(root)
+}
+
+func newDirContext(root string) (*dirContext, error) {
+  root, err := filepath.Abs(root)
+  if err != nil {
+    return nil, err
+  }
+  if !isUNC(root) {
+    if root, err = filepath.EvalSymlinks(root); err != nil {
+      return nil, err
+    }
+  }
+
+  c := &dirContext{root: root}
+  f, err := os.Open(filepath.Join(root, ".provignore"))
+  switch {
+  case os.IsNotExist(err):
+    return c, nil
+  case err != nil:
+    return nil, err
+  }
+  defer f.Close()
+
+  if c.excludes, err = provignore.ReadAll(f); err != nil {
+    return nil, err
+  }
+  if _, _, c.exceptions, err = fileutils.CleanPatterns(c.excludes); err != nil {
+    return nil, err
+  }
+  return c, nil
+}
+
+// Close does nothing, the directory belongs to the caller.
+func (c *dirContext) Close() error {
+  return nil
+}
+
+// Stat returns the path relative to the root of the file path resolves to,
+// following symbolic links, and its FileInfo.
+func (c *dirContext) Stat(path string) (string, FileInfo, error) {
+  cleanpath, fullpath, err := c.normalize(path)
+  if err != nil {
+    return "", nil, err
+  }
+  st, err := os.Lstat(fullpath)
+  if err != nil {
+    return "", nil, convertPathError(err, cleanpath)
+  }
+  rel, err := filepath.Rel(c.root, fullpath)
+  if err != nil {
+    return "", nil, convertPathError(err, cleanpath)
+  }
+  return rel, PathFileInfo{FileInfo: st, FilePath: fullpath, FileName: filepath.Base(cleanpath)}, nil
+}
+
+// Open opens the file path resolves to.
+func (c *dirContext) Open(path string) (io.ReadCloser, error) {
+  cleanpath, fullpath, err := c.normalize(path)
+  if err != nil {
+    return nil, err
+  }
+  r, err := os.Open(fullpath)
+  if err != nil {
+    return nil, convertPathError(err, cleanpath)
+  }
+  return r, nil
+}
+
+// Walk walks the tree under root, relative to the root of the context,
+// calling walkFn with paths relative to the root of the context. Files
+// excluded by .provignore are skipped.
+func (c *dirContext) Walk(root string, walkFn WalkFunc) error {
+  _, fullpath, err := c.normalize(root)
+  if err != nil {
+    return err
+  }
+  return filepath.Walk(fullpath, func(filePath string, info os.FileInfo, err error) error {
+    rel, relErr := filepath.Rel(c.root, filePath)
+    if relErr != nil {
+      return relErr
+    }
+    if rel == "." {
+      return nil
+    }
+    if err != nil {
+      return walkFn(rel, nil, err)
+    }
+
+    if skip, err := c.isExcluded(rel); err != nil {
+      return err
+    } else if skip {
+      if info.IsDir() && !c.exceptions {
+        return filepath.SkipDir
+      }
+      return nil
+    }
+    return walkFn(rel, PathFileInfo{FileInfo: info, FilePath: filePath}, nil)
+  })
+}
+
+// Remove deletes the file or directory path resolves to.
+func (c *dirContext) Remove(path string) error {
+  _, fullpath, err := c.normalize(path)
+  if err != nil {
+    return err
+  }
+  return os.RemoveAll(fullpath)
+}
+
+func (c *dirContext) isExcluded(rel string) (bool, error) {
+  if len(c.excludes) == 0 {
+    return false, nil
+  }
+  return fileutils.Matches(filepath.ToSlash(rel), c.excludes)
+}
+
+// normalize returns path cleaned and relative to the root, and the absolute
+// path it resolves to. Symbolic links are resolved as if the root was the
+// root of the filesystem, so the result never escapes the context.
+func (c *dirContext) normalize(path string) (cleanpath, fullpath string, err error) {
+  cleanpath = filepath.Clean(string(os.PathSeparator) + path)[1:]
+  fullpath, err = symlink.FollowSymlinkInScope(filepath.Join(c.root, cleanpath), c.root)
+  if err != nil {
+    return "", "", fmt.Errorf("Forbidden path outside the build context: %s (%s)", path, fullpath)
+  }
+  return cleanpath, fullpath, nil
+}
+
+// convertPathError reports path errors with the path of the context rather
+// than the absolute path of the file.
+func convertPathError(err error, cleanpath string) error {
+  if err, ok := err.(*os.PathError); ok {
+    err.Path = cleanpath
+    return err
+  }
+  return err
+}
@@ -0,0 +1,136 @@
+package builder
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "reflect"
+  "testing"
+)
+
+func prepareDirContext(t *testing.T) (string, func()) {
+  contextDir, cleanup := createTestTempDir(t, "", "builder-dircontext-test")
+  createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, 0644)
+  createTestTempFile(t, contextDir, ".provignore", "logs\n*.tmp\n", 0644)
+  createTestTempFile(t, contextDir, "model.json", "{}", 0644)
+  createTestTempFile(t, contextDir, "cache.tmp", "", 0644)
+  if err := os.MkdirAll(filepath.Join(contextDir, "weights"), 0755); err != nil {
+    t.Fatal(err)
+  }
+  createTestTempFile(t, contextDir, filepath.Join("weights", "a.bin"), "a", 0644)
+  if err := os.MkdirAll(filepath.Join(contextDir, "logs"), 0755); err != nil {
+    t.Fatal(err)
+  }
+  createTestTempFile(t, contextDir, filepath.Join("logs", "1.log"), "", 0644)
+  for link, target := range map[string]string{
+    "latest":   "weights/a.bin",
+    "escape":   "../../model.json",
+    "absolute": "/model.json",
+  } {
+    if err := os.Symlink(target, filepath.Join(contextDir, link)); err != nil {
+      t.Fatal(err)
+    }
+  }
+  return contextDir, cleanup
+}
+
+func TestDirContextStat(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+
+  c, err := NewDirectoryContext(contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer c.Close()
+
+  // Symbolic links resolve inside the context, even when they point
+  // above or outside of it
+  cases := map[string]string{
+    "latest":                 filepath.Join("weights", "a.bin"),
+    "escape":                 "model.json",
+    "absolute":               "model.json",
+    "/weights/../model.json": "model.json",
+  }
+  for path, expected := range cases {
+    rel, fi, err := c.Stat(path)
+    if err != nil {
+      t.Fatalf("unexpected error for %s: %v", path, err)
+    }
+    if rel != expected {
+      t.Fatalf("expected %s to resolve to %s, got %s", path, expected, rel)
+    }
+    if fi.Path() != filepath.Join(contextDir, expected) {
+      t.Fatalf("expected the absolute path of %s, got %s", expected, fi.Path())
+    }
+  }
+
+  if _, _, err := c.Stat("missing.json"); !os.IsNotExist(err) {
+    t.Fatalf("expected a not exist error, got %v", err)
+  }
+}
+
+func TestDirContextOpen(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+
+  c, err := NewDirectoryContext(contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  r, err := c.Open("latest")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer r.Close()
+  content, err := ioutil.ReadAll(r)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if string(content) != "a" {
+    t.Fatalf("expected the content of weights/a.bin, got %q", content)
+  }
+}
+
+func TestDirContextWalk(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+
+  c, err := NewDirectoryContext(contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  var paths []string
+  err = c.Walk("", func(path string, fi FileInfo, err error) error {
+    if err != nil {
+      return err
+    }
+    paths = append(paths, filepath.ToSlash(path))
+    return nil
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  expected := []string{".provignore", DefaultEnginefileName, "absolute", "escape", "latest", "model.json", "weights", "weights/a.bin"}
+  if !reflect.DeepEqual(paths, expected) {
+    t.Fatalf("expected %v, got %v", expected, paths)
+  }
+}
+
+func TestDirContextRemove(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+
+  c, err := NewDirectoryContext(contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := c.Remove("weights"); err != nil {
+    t.Fatal(err)
+  }
+  if _, err := os.Lstat(filepath.Join(contextDir, "weights")); !os.IsNotExist(err) {
+    t.Fatalf("expected weights to be removed, got %v", err)
+  }
+}
@@ -0,0 +1,131 @@
+package enginefile
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+
+  "github.com/TopPano/providence-cli/builder/enginefile/parser"
+  "github.com/docker/docker/pkg/symlink"
+)
+
+// ContextSources reads an Enginefile from r and returns the paths, relative
+// to contextDir and using slashes, of the files and directories its COPY
+// and ADD instructions read from the build context. Globs are expanded.
+//
+// An error is returned when a source can't be resolved statically, for
+// example because it references a variable or doesn't match any file:
+// the whole build context is needed then.
+func ContextSources(r io.Reader, contextDir string) ([]string, error) {
+  result, err := parser.Parse(r)
+  if err != nil {
+    return nil, err
+  }
+
+  seen := map[string]bool{}
+  var sources []string
+  for _, instruction := range result.Instructions {
+    for _, source := range CopySources(instruction) {
+      paths, err := resolveSource(contextDir, source)
+      if err != nil {
+        return nil, fmt.Errorf("line %d: %s source %q %v", instruction.Range.Start.Line, instruction.Keyword, source, err)
+      }
+      for _, path := range paths {
+        if !seen[path] {
+          seen[path] = true
+          sources = append(sources, path)
+        }
+      }
+    }
+  }
+  sort.Strings(sources)
+  return sources, nil
+}
+
+// resolveSource returns the paths of the build context matched by source.
+func resolveSource(contextDir, source string) ([]string, error) {
+  if HasVariables(source) {
+    return nil, fmt.Errorf("references a variable")
+  }
+  rel, ok := contextPath(source)
+  if !ok {
+    return nil, fmt.Errorf("is outside of the build context")
+  }
+
+  if !isGlob(rel) {
+    if _, err := os.Lstat(filepath.Join(contextDir, rel)); err != nil {
+      return nil, fmt.Errorf("not found in the build context")
+    }
+    return withSymlinkTarget(contextDir, rel)
+  }
+
+  matches, err := filepath.Glob(filepath.Join(contextDir, rel))
+  if err != nil || len(matches) == 0 {
+    return nil, fmt.Errorf("doesn't match any file in the build context")
+  }
+  var paths []string
+  for _, match := range matches {
+    rel, err := filepath.Rel(contextDir, match)
+    if err != nil {
+      return nil, err
+    }
+    withTarget, err := withSymlinkTarget(contextDir, rel)
+    if err != nil {
+      return nil, err
+    }
+    paths = append(paths, withTarget...)
+  }
+  return paths, nil
+}
+
+// withSymlinkTarget returns the path rel of the build context with the
+// path of its target when it is a symbolic link, which COPY and ADD
+// follow. Links to other links and paths under linked directories can't
+// be resolved statically.
+func withSymlinkTarget(contextDir, rel string) ([]string, error) {
+  path := filepath.Join(contextDir, rel)
+  if dir, err := symlink.FollowSymlinkInScope(filepath.Dir(path), contextDir); err != nil || dir != filepath.Dir(path) {
+    return nil, fmt.Errorf("is under a symbolic link")
+  }
+  paths := []string{filepath.ToSlash(rel)}
+  fi, err := os.Lstat(path)
+  if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+    return paths, err
+  }
+
+  linkname, err := os.Readlink(path)
+  if err != nil {
+    return nil, err
+  }
+  if filepath.IsAbs(linkname) {
+    linkname = filepath.Join(contextDir, linkname)
+  } else {
+    linkname = filepath.Join(filepath.Dir(path), linkname)
+  }
+  target, err := symlink.FollowSymlinkInScope(path, contextDir)
+  if err != nil || target != linkname {
+    return nil, fmt.Errorf("links to a symbolic link or outside of the build context")
+  }
+  targetRel, err := filepath.Rel(contextDir, target)
+  if err != nil {
+    return nil, err
+  }
+  return append(paths, filepath.ToSlash(targetRel)), nil
+}
+
+// contextPath returns the path of source relative to the root of the build
+// context, or false if it points outside of it.
+func contextPath(source string) (string, bool) {
+  rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(filepath.ToSlash(source), "/")))
+  if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+    return "", false
+  }
+  return rel, true
+}
+
+func isGlob(path string) bool {
+  return strings.ContainsAny(path, "*?[")
+}
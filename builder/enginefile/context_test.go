@@ -0,0 +1,89 @@
+package enginefile
+
+import (
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+func TestContextSources(t *testing.T) {
+  contextDir := newContextDir(t, "model.json", "weights/a.bin", "weights/b.bin", "labels/en.txt", "labels/fr.txt", "README.md")
+  defer os.RemoveAll(contextDir)
+
+  enginefile := `FROM scratch
+COPY model.json /srv/
+COPY ["weights", "/srv/weights"]
+ADD labels/*.txt https://example.com/extra.txt /srv/labels/
+COPY /model.json ./weights/../model.json /srv/copy/
+COPY --from=builder /out /srv/
+`
+  sources, err := ContextSources(strings.NewReader(enginefile), contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected := []string{"labels/en.txt", "labels/fr.txt", "model.json", "weights"}
+  if !reflect.DeepEqual(sources, expected) {
+    t.Fatalf("expected %v, got %v", expected, sources)
+  }
+}
+
+func TestContextSourcesSymlinks(t *testing.T) {
+  contextDir := newContextDir(t, "models/v2.json", "weights/a.bin")
+  defer os.RemoveAll(contextDir)
+  for link, target := range map[string]string{
+    "model.json":  "models/v2.json",
+    "current":     "/weights",
+    "latest.json": "model.json",
+    "linked":      "weights",
+  } {
+    if err := os.Symlink(target, filepath.Join(contextDir, link)); err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  sources, err := ContextSources(strings.NewReader("FROM scratch\nCOPY model.json current /srv/\n"), contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected := []string{"current", "model.json", "models/v2.json", "weights"}
+  if !reflect.DeepEqual(sources, expected) {
+    t.Fatalf("expected %v, got %v", expected, sources)
+  }
+
+  cases := []struct {
+    enginefile string
+    message    string
+  }{
+    {"FROM scratch\nCOPY latest.json /srv/\n", `line 2: COPY source "latest.json" links to a symbolic link or outside of the build context`},
+    {"FROM scratch\nCOPY linked/a.bin /srv/\n", `line 2: COPY source "linked/a.bin" is under a symbolic link`},
+  }
+  for _, c := range cases {
+    _, err := ContextSources(strings.NewReader(c.enginefile), contextDir)
+    if err == nil || err.Error() != c.message {
+      t.Errorf("expected %q, got %v", c.message, err)
+    }
+  }
+}
+
+func TestContextSourcesUnresolvable(t *testing.T) {
+  contextDir := newContextDir(t, "model.json")
+  defer os.RemoveAll(contextDir)
+
+  cases := []struct {
+    enginefile string
+    message    string
+  }{
+    {"FROM scratch\nARG model\nCOPY ${model}.json /srv/\n", `line 3: COPY source "${model}.json" references a variable`},
+    {"FROM scratch\nCOPY missing.json /srv/\n", `line 2: COPY source "missing.json" not found in the build context`},
+    {"FROM scratch\nCOPY *.yml /srv/\n", `line 2: COPY source "*.yml" doesn't match any file in the build context`},
+    {"FROM scratch\nCOPY ../model.json /srv/\n", `line 2: COPY source "../model.json" is outside of the build context`},
+  }
+  for _, c := range cases {
+    _, err := ContextSources(strings.NewReader(c.enginefile), contextDir)
+    if err == nil || err.Error() != c.message {
+      t.Errorf("expected %q, got %v", c.message, err)
+    }
+  }
+}
@@ -0,0 +1,87 @@
+// Package enginefile gives meaning to the instructions of an Enginefile
+// parsed by the parser package: which instructions exist, which files
+// they read from the build context, and how to check them locally before
+// sending a build to the server.
+package enginefile
+
+import (
+  "regexp"
+  "strings"
+
+  "github.com/TopPano/providence-cli/builder/enginefile/parser"
+)
+
+// minArgs maps each instruction of the Enginefile format to the minimum
+// number of arguments it takes.
+var minArgs = map[string]int{
+  "ADD":         2,
+  "ARG":         1,
+  "CMD":         1,
+  "COPY":        2,
+  "ENTRYPOINT":  1,
+  "ENV":         1,
+  "EXPOSE":      1,
+  "FROM":        1,
+  "HEALTHCHECK": 1,
+  "LABEL":       1,
+  "ONBUILD":     1,
+  "RUN":         1,
+  "SHELL":       1,
+  "STOPSIGNAL":  1,
+  "USER":        1,
+  "VOLUME":      1,
+  "WORKDIR":     1,
+}
+
+var variableRegexp = regexp.MustCompile(`\$(?:\{([a-zA-Z_][a-zA-Z0-9_]*)[^}]*\}|([a-zA-Z_][a-zA-Z0-9_]*))`)
+
+// IsInstruction returns true if keyword is an instruction of the
+// Enginefile format.
+func IsInstruction(keyword string) bool {
+  _, ok := minArgs[strings.ToUpper(keyword)]
+  return ok
+}
+
+// IsCopy returns true if the instruction copies files from the build
+// context, that is a COPY or ADD instruction without a --from flag.
+func IsCopy(instruction *parser.Instruction) bool {
+  if instruction.Keyword != "COPY" && instruction.Keyword != "ADD" {
+    return false
+  }
+  for _, flag := range instruction.Flags {
+    if strings.HasPrefix(flag, "--from=") {
+      return false
+    }
+  }
+  return true
+}
+
+// CopySources returns the paths of the build context read by a COPY or
+// ADD instruction, as written in the Enginefile. Heredocs and remote URLs
+// are not part of the build context and are left out.
+func CopySources(instruction *parser.Instruction) []string {
+  if !IsCopy(instruction) || len(instruction.Args) < 2 {
+    return nil
+  }
+  var sources []string
+  for _, arg := range instruction.Args[:len(instruction.Args)-1] {
+    if strings.HasPrefix(arg, "<<") && !instruction.JSONForm {
+      continue
+    }
+    if instruction.Keyword == "ADD" && isRemote(arg) {
+      continue
+    }
+    sources = append(sources, arg)
+  }
+  return sources
+}
+
+// HasVariables returns true if s references variables, which means it can
+// only be resolved by the builder.
+func HasVariables(s string) bool {
+  return variableRegexp.MatchString(s)
+}
+
+func isRemote(s string) bool {
+  return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
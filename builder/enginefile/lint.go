@@ -0,0 +1,182 @@
+package enginefile
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+
+  "github.com/TopPano/providence-cli/builder/enginefile/parser"
+  "github.com/docker/docker/pkg/fileutils"
+)
+
+// Rules reported by Lint
+const (
+  RuleSyntax             = "syntax"
+  RuleUnknownInstruction = "unknown-instruction"
+  RuleMissingArguments   = "missing-arguments"
+  RuleMissingFile        = "missing-file"
+  RuleExcludedFile       = "excluded-file"
+  RuleUndefinedBuildArg  = "undefined-build-arg"
+)
+
+// Finding is a problem found in an Enginefile. Line and Column are 0 for
+// problems that aren't tied to an instruction.
+type Finding struct {
+  File    string `json:"file"`
+  Line    int    `json:"line"`
+  Column  int    `json:"column"`
+  Rule    string `json:"rule"`
+  Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+  if f.Line == 0 {
+    return fmt.Sprintf("%s: %s (%s)", f.File, f.Message, f.Rule)
+  }
+  return fmt.Sprintf("%s:%d:%d: %s (%s)", f.File, f.Line, f.Column, f.Message, f.Rule)
+}
+
+// LintOptions holds the parameters of Lint.
+type LintOptions struct {
+  // Filename is the name of the Enginefile reported in the findings.
+  Filename string
+  // ContextDir is the build context directory. Files copied from the
+  // build context are only checked when it is set.
+  ContextDir string
+  // Excludes are the .provignore patterns of the build context.
+  Excludes []string
+  // BuildArgs are the build-time variables passed to the build.
+  BuildArgs map[string]*string
+}
+
+// Lint reads an Enginefile from r and returns the problems it found. Syntax
+// errors are reported as findings; the error is only set when r can't be
+// read.
+func Lint(r io.Reader, options LintOptions) ([]Finding, error) {
+  result, err := parser.Parse(r)
+  if err != nil {
+    perr, ok := err.(*parser.Error)
+    if !ok {
+      return nil, err
+    }
+    return []Finding{{
+      File:    options.Filename,
+      Line:    perr.Position.Line,
+      Column:  perr.Position.Column,
+      Rule:    RuleSyntax,
+      Message: perr.Message,
+    }}, nil
+  }
+
+  l := &linter{options: options, args: map[string]bool{}}
+  for _, instruction := range result.Instructions {
+    l.lintInstruction(instruction)
+  }
+  l.lintBuildArgs()
+  return l.findings, nil
+}
+
+type linter struct {
+  options  LintOptions
+  args     map[string]bool
+  findings []Finding
+}
+
+func (l *linter) report(instruction *parser.Instruction, rule, format string, a ...interface{}) {
+  finding := Finding{File: l.options.Filename, Rule: rule, Message: fmt.Sprintf(format, a...)}
+  if instruction != nil {
+    finding.Line = instruction.Range.Start.Line
+    finding.Column = instruction.Range.Start.Column
+  }
+  l.findings = append(l.findings, finding)
+}
+
+func (l *linter) lintInstruction(instruction *parser.Instruction) {
+  min, ok := minArgs[instruction.Keyword]
+  if !ok {
+    l.report(instruction, RuleUnknownInstruction, "unknown instruction: %s", instruction.Keyword)
+    return
+  }
+  if len(instruction.Args) < min {
+    if min == 1 {
+      l.report(instruction, RuleMissingArguments, "%s requires at least one argument", instruction.Keyword)
+    } else {
+      l.report(instruction, RuleMissingArguments, "%s requires at least %d arguments", instruction.Keyword, min)
+    }
+    return
+  }
+
+  // Variables can't be checked locally, the base image may define them
+  if instruction.Keyword == "ARG" {
+    for _, arg := range instruction.Args {
+      l.args[strings.SplitN(arg, "=", 2)[0]] = true
+    }
+  }
+
+  if l.options.ContextDir != "" {
+    for _, source := range CopySources(instruction) {
+      l.lintSource(instruction, source)
+    }
+  }
+}
+
+// lintSource checks that a file copied from the build context exists and
+// isn't excluded by .provignore.
+func (l *linter) lintSource(instruction *parser.Instruction, source string) {
+  if HasVariables(source) {
+    return
+  }
+  rel, ok := contextPath(source)
+  if !ok {
+    l.report(instruction, RuleMissingFile, "%s source %q is outside of the build context", instruction.Keyword, source)
+    return
+  }
+
+  if !isGlob(rel) {
+    if _, err := os.Lstat(filepath.Join(l.options.ContextDir, rel)); err != nil {
+      l.report(instruction, RuleMissingFile, "%s source %q not found in the build context", instruction.Keyword, source)
+    } else if l.isExcluded(rel) {
+      l.report(instruction, RuleExcludedFile, "%s source %q is excluded by .provignore", instruction.Keyword, source)
+    }
+    return
+  }
+
+  matches, err := filepath.Glob(filepath.Join(l.options.ContextDir, rel))
+  if err != nil || len(matches) == 0 {
+    l.report(instruction, RuleMissingFile, "no file in the build context matches %s source %q", instruction.Keyword, source)
+    return
+  }
+  for _, match := range matches {
+    rel, err := filepath.Rel(l.options.ContextDir, match)
+    if err == nil && !l.isExcluded(rel) {
+      return
+    }
+  }
+  l.report(instruction, RuleExcludedFile, "all the files matching %s source %q are excluded by .provignore", instruction.Keyword, source)
+}
+
+func (l *linter) isExcluded(rel string) bool {
+  if rel == "." || len(l.options.Excludes) == 0 {
+    return false
+  }
+  excluded, err := fileutils.Matches(filepath.ToSlash(rel), l.options.Excludes)
+  return err == nil && excluded
+}
+
+// lintBuildArgs checks that the build args passed to the build are declared
+// by an ARG instruction.
+func (l *linter) lintBuildArgs() {
+  var names []string
+  for name := range l.options.BuildArgs {
+    if !l.args[name] {
+      names = append(names, name)
+    }
+  }
+  sort.Strings(names)
+  for _, name := range names {
+    l.report(nil, RuleUndefinedBuildArg, "build arg %q is not defined by an ARG instruction", name)
+  }
+}
@@ -0,0 +1,113 @@
+package enginefile
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+func newContextDir(t *testing.T, files ...string) string {
+  dir, err := ioutil.TempDir("", "enginefile-lint-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  for _, file := range files {
+    path := filepath.Join(dir, file)
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+      t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(path, []byte(file), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  return dir
+}
+
+func TestLint(t *testing.T) {
+  contextDir := newContextDir(t, "model.json", "weights/a.bin", "secrets/token", "logs/1.log")
+  defer os.RemoveAll(contextDir)
+
+  enginefile := `FROM scratch
+ARG model
+FORM scratch
+COPY model.json
+COPY model.json weights /srv/
+COPY ${model}/config.yml /srv/
+COPY missing.yml /srv/
+COPY secrets/token /srv/
+COPY logs/*.log /srv/
+COPY *.yml /srv/
+ADD <<CONFIG https://example.com/a /srv/
+CONFIG
+WORKDIR /srv/$version
+COPY --from=builder /out /srv/
+`
+  findings, err := Lint(strings.NewReader(enginefile), LintOptions{
+    Filename:   "Enginefile",
+    ContextDir: contextDir,
+    Excludes:   []string{"secrets", "logs/*.log"},
+    BuildArgs:  map[string]*string{"model": nil, "batch": nil},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  var actual []string
+  for _, f := range findings {
+    actual = append(actual, f.String())
+  }
+  expected := []string{
+    "Enginefile:3:1: unknown instruction: FORM (unknown-instruction)",
+    "Enginefile:4:1: COPY requires at least 2 arguments (missing-arguments)",
+    `Enginefile:7:1: COPY source "missing.yml" not found in the build context (missing-file)`,
+    `Enginefile:8:1: COPY source "secrets/token" is excluded by .provignore (excluded-file)`,
+    `Enginefile:9:1: all the files matching COPY source "logs/*.log" are excluded by .provignore (excluded-file)`,
+    `Enginefile:10:1: no file in the build context matches COPY source "*.yml" (missing-file)`,
+    `Enginefile: build arg "batch" is not defined by an ARG instruction (undefined-build-arg)`,
+  }
+  if !reflect.DeepEqual(actual, expected) {
+    t.Fatalf("expected findings:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+  }
+}
+
+func TestLintSyntaxError(t *testing.T) {
+  findings, err := Lint(strings.NewReader("FROM scratch\nRUN <<SCRIPT\n"), LintOptions{Filename: "Enginefile"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected := []Finding{{File: "Enginefile", Line: 2, Column: 1, Rule: RuleSyntax, Message: "unterminated heredoc SCRIPT"}}
+  if !reflect.DeepEqual(findings, expected) {
+    t.Fatalf("expected %v, got %v", expected, findings)
+  }
+}
+
+func TestLintWithoutContextDir(t *testing.T) {
+  findings, err := Lint(strings.NewReader("FROM scratch\nCOPY missing.yml /srv/\n"), LintOptions{Filename: "Enginefile"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(findings) != 0 {
+    t.Fatalf("expected files not to be checked, got %v", findings)
+  }
+}
+
+func TestLintVariables(t *testing.T) {
+  // Variables may come from the base image and escaped ones aren't
+  // expanded, none of them is a problem
+  enginefile := `FROM base
+ENV PATH=$PATH:/opt/bin
+WORKDIR $HOME
+LABEL price=\$5 version=${VERSION:-1.0}
+RUN echo \$HOME
+`
+  findings, err := Lint(strings.NewReader(enginefile), LintOptions{Filename: "Enginefile"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(findings) != 0 {
+    t.Fatalf("expected no findings, got %v", findings)
+  }
+}
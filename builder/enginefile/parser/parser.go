@@ -0,0 +1,393 @@
+// Package parser implements a parser for Enginefiles.
+//
+// Parse turns an Enginefile into a list of instructions, each carrying the
+// position it was read from. The parser understands comments, parser
+// directives such as `# escape=`, line continuations and heredocs, but it
+// doesn't know which instructions exist nor what their arguments mean:
+// that is left to the tools built on top of it.
+package parser
+
+import (
+  "bufio"
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "io"
+  "regexp"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+)
+
+// DefaultEscapeToken is the escape token used when the Enginefile doesn't
+// set one with the escape directive.
+const DefaultEscapeToken = '\\'
+
+// Position is a location in an Enginefile. Lines and columns start at 1 and
+// columns count characters, not bytes.
+type Position struct {
+  Line   int
+  Column int
+}
+
+func (p Position) String() string {
+  return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Range is the part of an Enginefile spanning from Start to End, End being
+// the position right after the last character.
+type Range struct {
+  Start Position
+  End   Position
+}
+
+// Directive is a parser directive, such as the escape directive.
+type Directive struct {
+  Name     string
+  Value    string
+  Position Position
+}
+
+// Comment is a comment line. Parser directives are not comments.
+type Comment struct {
+  // Text is the comment without the leading '#' and surrounding spaces.
+  Text     string
+  Position Position
+}
+
+// Heredoc is an inline file or script introduced by `<<NAME` in an
+// instruction, and ending with a line containing only NAME.
+type Heredoc struct {
+  Name string
+  // Content is the body of the heredoc, each line ending with a new line.
+  Content string
+  // Expand is false when the name was quoted, meaning variables in the
+  // content must not be expanded.
+  Expand bool
+  // Chomp is true for `<<-NAME`, whose leading tabs are removed.
+  Chomp bool
+  Range Range
+}
+
+// Instruction is a single instruction of an Enginefile.
+type Instruction struct {
+  // Keyword is the name of the instruction, in upper case.
+  Keyword string
+  // Flags are the `--name=value` arguments preceding the other arguments.
+  Flags []string
+  // Args are the arguments of the instruction. In the shell form they
+  // are split on white spaces, with quotes and escapes removed. Heredocs
+  // appear as their `<<NAME` word.
+  Args []string
+  // RawArgs is the text following the keyword and the flags, with the
+  // continuation lines joined.
+  RawArgs string
+  // JSONForm is true when the arguments were given as a JSON array.
+  JSONForm bool
+  // Heredocs are the heredocs of the instruction, in order.
+  Heredocs []Heredoc
+  // Original is the source text of the instruction, including its
+  // continuation lines and heredocs.
+  Original string
+  Range    Range
+}
+
+// Result is the result of parsing an Enginefile.
+type Result struct {
+  Instructions []*Instruction
+  Directives   []Directive
+  Comments     []Comment
+  // EscapeToken is the escape token used by the Enginefile.
+  EscapeToken rune
+}
+
+// Error is a syntax error in an Enginefile.
+type Error struct {
+  Position Position
+  Message  string
+}
+
+func (e *Error) Error() string {
+  return fmt.Sprintf("line %d: %s", e.Position.Line, e.Message)
+}
+
+var (
+  directiveRegexp = regexp.MustCompile(`^#[ \t]*([a-zA-Z][a-zA-Z0-9]*)[ \t]*=[ \t]*(.+?)[ \t]*$`)
+  heredocRegexp   = regexp.MustCompile(`^<<(-?)(["']?)([a-zA-Z_][a-zA-Z0-9_]*)(["']?)$`)
+)
+
+// knownDirectives are the directives recognized at the top of an Enginefile.
+var knownDirectives = map[string]bool{
+  "escape": true,
+  "syntax": true,
+}
+
+// Parse reads an Enginefile from r and returns its instructions.
+func Parse(r io.Reader) (*Result, error) {
+  lines, err := readLines(r)
+  if err != nil {
+    return nil, err
+  }
+  p := &parser{
+    lines:  lines,
+    escape: DefaultEscapeToken,
+    result: &Result{EscapeToken: DefaultEscapeToken},
+  }
+  if err := p.parse(); err != nil {
+    return nil, err
+  }
+  return p.result, nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+  var lines []string
+  scanner := bufio.NewScanner(r)
+  scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+  utf8bom := []byte{0xEF, 0xBB, 0xBF}
+  for scanner.Scan() {
+    line := scanner.Bytes()
+    if len(lines) == 0 {
+      line = bytes.TrimPrefix(line, utf8bom)
+    }
+    lines = append(lines, strings.TrimSuffix(string(line), "\r"))
+  }
+  if err := scanner.Err(); err != nil {
+    return nil, fmt.Errorf("Error reading Enginefile: %v", err)
+  }
+  return lines, nil
+}
+
+type parser struct {
+  lines  []string
+  next   int
+  escape rune
+  result *Result
+}
+
+func (p *parser) parse() error {
+  if err := p.parseDirectives(); err != nil {
+    return err
+  }
+
+  for p.next < len(p.lines) {
+    lineNo := p.next + 1
+    line := p.lines[p.next]
+    p.next++
+
+    trimmed := strings.TrimSpace(line)
+    switch {
+    case trimmed == "":
+    case strings.HasPrefix(trimmed, "#"):
+      p.result.Comments = append(p.result.Comments, Comment{
+        Text:     strings.TrimSpace(trimmed[1:]),
+        Position: Position{Line: lineNo, Column: indent(line) + 1},
+      })
+    default:
+      instruction, err := p.parseInstruction(line, lineNo)
+      if err != nil {
+        return err
+      }
+      p.result.Instructions = append(p.result.Instructions, instruction)
+    }
+  }
+  return nil
+}
+
+// parseDirectives reads the parser directives at the top of the file. The
+// first line that isn't a known directive ends them.
+func (p *parser) parseDirectives() error {
+  seen := map[string]bool{}
+  for ; p.next < len(p.lines); p.next++ {
+    m := directiveRegexp.FindStringSubmatch(p.lines[p.next])
+    if m == nil {
+      return nil
+    }
+    name := strings.ToLower(m[1])
+    if !knownDirectives[name] {
+      return nil
+    }
+    pos := Position{Line: p.next + 1, Column: 1}
+    if seen[name] {
+      return &Error{Position: pos, Message: fmt.Sprintf("only one %s parser directive can be used", name)}
+    }
+    seen[name] = true
+
+    if name == "escape" {
+      if m[2] != "\\" && m[2] != "`" {
+        return &Error{Position: pos, Message: fmt.Sprintf("invalid escape token %q does not match ` or \\", m[2])}
+      }
+      p.escape = rune(m[2][0])
+      p.result.EscapeToken = p.escape
+    }
+    p.result.Directives = append(p.result.Directives, Directive{Name: name, Value: m[2], Position: pos})
+  }
+  return nil
+}
+
+func (p *parser) parseInstruction(line string, lineNo int) (*Instruction, error) {
+  start := Position{Line: lineNo, Column: indent(line) + 1}
+  original := []string{line}
+  endLine, endText := lineNo, line
+
+  text, cont := p.trimContinuation(line)
+  for cont && p.next < len(p.lines) {
+    next := p.lines[p.next]
+    p.next++
+    original = append(original, next)
+    endLine, endText = p.next, next
+
+    // Empty lines and comments don't end a continuation
+    trimmed := strings.TrimSpace(next)
+    if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+      continue
+    }
+    var part string
+    part, cont = p.trimContinuation(next)
+    text += part
+  }
+
+  text = strings.TrimSpace(text)
+  keyword, rest := text, ""
+  if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+    keyword, rest = text[:i], strings.TrimSpace(text[i:])
+  }
+
+  instruction := &Instruction{Keyword: strings.ToUpper(keyword)}
+  instruction.Flags, rest = splitFlags(rest)
+  instruction.RawArgs = rest
+
+  if strings.HasPrefix(rest, "[") {
+    var args []string
+    if err := json.Unmarshal([]byte(rest), &args); err == nil {
+      instruction.Args = args
+      instruction.JSONForm = true
+    }
+  }
+  if !instruction.JSONForm {
+    instruction.Args = p.splitWords(rest)
+    // Look for heredocs before quotes are removed, they tell whether
+    // the content is expanded
+    for _, word := range strings.Fields(rest) {
+      m := heredocRegexp.FindStringSubmatch(word)
+      if m == nil || m[2] != m[4] {
+        continue
+      }
+      heredoc, err := p.readHeredoc(m[3], m[1] == "-", m[2] == "")
+      if err != nil {
+        return nil, &Error{Position: start, Message: err.Error()}
+      }
+      instruction.Heredocs = append(instruction.Heredocs, heredoc)
+      original = append(original, p.lines[heredoc.Range.Start.Line-1:heredoc.Range.End.Line]...)
+      endLine, endText = heredoc.Range.End.Line, p.lines[heredoc.Range.End.Line-1]
+    }
+  }
+
+  instruction.Original = strings.Join(original, "\n")
+  instruction.Range = Range{
+    Start: start,
+    End:   Position{Line: endLine, Column: utf8.RuneCountInString(strings.TrimRightFunc(endText, unicode.IsSpace)) + 1},
+  }
+  return instruction, nil
+}
+
+// readHeredoc reads the body of a heredoc starting at the next line.
+func (p *parser) readHeredoc(name string, chomp, expand bool) (Heredoc, error) {
+  heredoc := Heredoc{
+    Name:   name,
+    Expand: expand,
+    Chomp:  chomp,
+    Range:  Range{Start: Position{Line: p.next + 1, Column: 1}},
+  }
+  var content bytes.Buffer
+  for p.next < len(p.lines) {
+    line := p.lines[p.next]
+    p.next++
+    if chomp {
+      line = strings.TrimLeft(line, "\t")
+    }
+    if line == name {
+      heredoc.Content = content.String()
+      heredoc.Range.End = Position{Line: p.next, Column: utf8.RuneCountInString(p.lines[p.next-1]) + 1}
+      return heredoc, nil
+    }
+    content.WriteString(line)
+    content.WriteByte('\n')
+  }
+  return heredoc, fmt.Errorf("unterminated heredoc %s", name)
+}
+
+// trimContinuation removes the escape token ending line, if any, and
+// reports whether the instruction continues on the next line.
+func (p *parser) trimContinuation(line string) (string, bool) {
+  trimmed := strings.TrimRightFunc(line, unicode.IsSpace)
+  if strings.HasSuffix(trimmed, string(p.escape)) {
+    return strings.TrimSuffix(trimmed, string(p.escape)), true
+  }
+  return line, false
+}
+
+// splitWords splits s on white spaces, removing quotes and escape tokens.
+// An unterminated quote runs to the end of s.
+func (p *parser) splitWords(s string) []string {
+  var (
+    words   []string
+    word    []rune
+    inWord  bool
+    quote   rune
+    escaped bool
+  )
+  for _, r := range s {
+    switch {
+    case escaped:
+      word = append(word, r)
+      escaped = false
+    case r == p.escape && quote != '\'':
+      escaped, inWord = true, true
+    case quote != 0:
+      if r == quote {
+        quote = 0
+      } else {
+        word = append(word, r)
+      }
+    case r == '\'' || r == '"':
+      quote, inWord = r, true
+    case unicode.IsSpace(r):
+      if inWord {
+        words = append(words, string(word))
+        word, inWord = nil, false
+      }
+    default:
+      word = append(word, r)
+      inWord = true
+    }
+  }
+  if escaped {
+    word = append(word, p.escape)
+  }
+  if inWord {
+    words = append(words, string(word))
+  }
+  return words
+}
+
+// splitFlags removes the leading `--flag` words of s.
+func splitFlags(s string) ([]string, string) {
+  var flags []string
+  for strings.HasPrefix(s, "--") {
+    end := strings.IndexFunc(s, unicode.IsSpace)
+    if end < 0 {
+      end = len(s)
+    }
+    if end == 2 {
+      // A lone "--" is an argument
+      break
+    }
+    flags = append(flags, s[:end])
+    s = strings.TrimSpace(s[end:])
+  }
+  return flags, s
+}
+
+func indent(line string) int {
+  return utf8.RuneCountInString(line) - utf8.RuneCountInString(strings.TrimLeftFunc(line, unicode.IsSpace))
+}
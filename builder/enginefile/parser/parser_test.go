@@ -0,0 +1,148 @@
+package parser
+
+import (
+  "reflect"
+  "strings"
+  "testing"
+)
+
+func parse(t *testing.T, content string) *Result {
+  result, err := Parse(strings.NewReader(content))
+  if err != nil {
+    t.Fatalf("failed to parse %q: %v", content, err)
+  }
+  return result
+}
+
+func TestParseInstructions(t *testing.T) {
+  result := parse(t, `FROM scratch
+# the model to serve
+  arg model=resnet
+
+COPY --from=weights ["weights/a.bin", "/srv/weights/"]
+COPY "my model.json" config.yml /srv/
+`)
+
+  if len(result.Instructions) != 4 {
+    t.Fatalf("expected 4 instructions, got %d", len(result.Instructions))
+  }
+
+  expected := []Instruction{
+    {Keyword: "FROM", Args: []string{"scratch"}, RawArgs: "scratch"},
+    {Keyword: "ARG", Args: []string{"model=resnet"}, RawArgs: "model=resnet"},
+    {Keyword: "COPY", Flags: []string{"--from=weights"}, Args: []string{"weights/a.bin", "/srv/weights/"}, RawArgs: `["weights/a.bin", "/srv/weights/"]`, JSONForm: true},
+    {Keyword: "COPY", Args: []string{"my model.json", "config.yml", "/srv/"}, RawArgs: `"my model.json" config.yml /srv/`},
+  }
+  for i, e := range expected {
+    actual := result.Instructions[i]
+    if actual.Keyword != e.Keyword || !reflect.DeepEqual(actual.Flags, e.Flags) || !reflect.DeepEqual(actual.Args, e.Args) ||
+      actual.RawArgs != e.RawArgs || actual.JSONForm != e.JSONForm {
+      t.Errorf("instruction %d: expected %+v, got %+v", i, e, *actual)
+    }
+  }
+
+  if start := result.Instructions[1].Range.Start; start != (Position{Line: 3, Column: 3}) {
+    t.Errorf("expected ARG to start at 3:3, got %s", start)
+  }
+  if end := result.Instructions[1].Range.End; end != (Position{Line: 3, Column: 19}) {
+    t.Errorf("expected ARG to end at 3:19, got %s", end)
+  }
+  if !reflect.DeepEqual(result.Comments, []Comment{{Text: "the model to serve", Position: Position{Line: 2, Column: 1}}}) {
+    t.Errorf("unexpected comments %+v", result.Comments)
+  }
+}
+
+func TestParseContinuations(t *testing.T) {
+  result := parse(t, "RUN make \\\n  # a comment\n\n  model \\\n  weights\nFROM scratch\n")
+
+  run := result.Instructions[0]
+  if !reflect.DeepEqual(run.Args, []string{"make", "model", "weights"}) {
+    t.Fatalf("unexpected args %v", run.Args)
+  }
+  if run.Range.Start.Line != 1 || run.Range.End.Line != 5 {
+    t.Fatalf("expected RUN to span lines 1 to 5, got %s-%s", run.Range.Start, run.Range.End)
+  }
+  if run.Original != "RUN make \\\n  # a comment\n\n  model \\\n  weights" {
+    t.Fatalf("unexpected original text %q", run.Original)
+  }
+  if from := result.Instructions[1]; from.Range.Start.Line != 6 {
+    t.Fatalf("expected FROM on line 6, got %s", from.Range.Start)
+  }
+}
+
+func TestParseEscapeDirective(t *testing.T) {
+  result := parse(t, "# escape=`\n# syntax=prov/enginefile:1\nCOPY C:\\models\\ `\n  C:\\srv\\\n")
+
+  if result.EscapeToken != '`' {
+    t.Fatalf("expected the escape token to be `, got %q", result.EscapeToken)
+  }
+  if len(result.Directives) != 2 || result.Directives[1].Name != "syntax" || result.Directives[1].Value != "prov/enginefile:1" {
+    t.Fatalf("unexpected directives %+v", result.Directives)
+  }
+  if args := result.Instructions[0].Args; !reflect.DeepEqual(args, []string{`C:\models\`, `C:\srv\`}) {
+    t.Fatalf("unexpected args %v", args)
+  }
+}
+
+func TestParseDirectivesOnlyAtTheTop(t *testing.T) {
+  result := parse(t, "FROM scratch\n# escape=`\nRUN a \\\n  b\n")
+
+  if result.EscapeToken != DefaultEscapeToken || len(result.Directives) != 0 {
+    t.Fatalf("expected the directive to be a comment, got %+v", result.Directives)
+  }
+  if args := result.Instructions[1].Args; !reflect.DeepEqual(args, []string{"a", "b"}) {
+    t.Fatalf("unexpected args %v", args)
+  }
+}
+
+func TestParseHeredocs(t *testing.T) {
+  // The SCRIPT heredoc is indented with tabs, which <<- strips
+  result := parse(t, "FROM scratch\n"+
+    "COPY <<CONFIG <<-'SCRIPT' /srv/\n"+
+    "threshold: 0.5\n"+
+    "CONFIG\n"+
+    "\techo $MODEL\n"+
+    "\tSCRIPT\n"+
+    "RUN true\n")
+
+  copy := result.Instructions[1]
+  expected := []Heredoc{
+    {Name: "CONFIG", Content: "threshold: 0.5\n", Expand: true, Range: Range{Start: Position{3, 1}, End: Position{4, 7}}},
+    {Name: "SCRIPT", Content: "echo $MODEL\n", Chomp: true, Range: Range{Start: Position{5, 1}, End: Position{6, 8}}},
+  }
+  if !reflect.DeepEqual(copy.Heredocs, expected) {
+    t.Fatalf("expected heredocs %+v, got %+v", expected, copy.Heredocs)
+  }
+  if !reflect.DeepEqual(copy.Args, []string{"<<CONFIG", "<<-SCRIPT", "/srv/"}) {
+    t.Fatalf("unexpected args %v", copy.Args)
+  }
+  if copy.Range.End != (Position{Line: 6, Column: 8}) {
+    t.Fatalf("expected COPY to end with its last heredoc, got %s", copy.Range.End)
+  }
+  if run := result.Instructions[2]; run.Keyword != "RUN" || run.Range.Start.Line != 7 {
+    t.Fatalf("expected RUN on line 7, got %+v", run)
+  }
+}
+
+func TestParseErrors(t *testing.T) {
+  cases := []struct {
+    content string
+    line    int
+    message string
+  }{
+    {"# escape=`\n# escape=\\\nFROM scratch\n", 2, "only one escape parser directive can be used"},
+    {"# escape=x\nFROM scratch\n", 1, "invalid escape token \"x\" does not match ` or \\"},
+    {"FROM scratch\nRUN <<EOF\necho\n", 2, "unterminated heredoc EOF"},
+  }
+  for _, c := range cases {
+    _, err := Parse(strings.NewReader(c.content))
+    perr, ok := err.(*Error)
+    if !ok {
+      t.Errorf("expected a syntax error for %q, got %v", c.content, err)
+      continue
+    }
+    if perr.Position.Line != c.line || perr.Message != c.message {
+      t.Errorf("expected %q on line %d, got %v", c.message, c.line, perr)
+    }
+  }
+}
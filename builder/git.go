@@ -0,0 +1,145 @@
+package builder
+
+import (
+  "fmt"
+  "io/ioutil"
+  "net/http"
+  "net/url"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "regexp"
+  "strings"
+
+  "github.com/docker/docker/pkg/symlink"
+  "github.com/docker/docker/pkg/urlutil"
+)
+
+const localGitPrefix = "file://"
+
+// scpGitRegexp matches the scp-like addresses of git repositories, such as
+// git@github.com:org/repo.git, which aren't URLs.
+var scpGitRegexp = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// IsGitURL returns true if the provided str is a git repository URL that can
+// be used as a build context. On top of the remote forms understood by
+// urlutil, a local repository can be referenced with a file:// URL.
+func IsGitURL(str string) bool {
+  return urlutil.IsGitURL(str) || strings.HasPrefix(str, localGitPrefix)
+}
+
+// cloneGitRepository clones the repository at remoteURL into a newly created
+// temporary directory. An optional `#ref:subdir` fragment selects the ref to
+// check out and the sub directory to use as the context root. It returns
+// the directory of the clone, which the caller must remove, and the context
+// directory inside it. Nothing is left behind on error.
+func cloneGitRepository(remoteURL string) (root, contextDir string, err error) {
+  repo, fragment, err := parseGitURL(remoteURL)
+  if err != nil {
+    return "", "", err
+  }
+
+  root, err = ioutil.TempDir("", "providence-build-git")
+  if err != nil {
+    return "", "", err
+  }
+  if output, err := git(cloneArgs(repo, fragment, root)...); err != nil {
+    os.RemoveAll(root)
+    return "", "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
+  }
+
+  if contextDir, err = checkoutGit(fragment, root); err != nil {
+    os.RemoveAll(root)
+    return "", "", err
+  }
+  return root, contextDir, nil
+}
+
+// parseGitURL splits remoteURL into the address of the repository to clone
+// and its `ref:subdir` fragment. Addresses without a transport default to
+// https, scp-like addresses are kept as they are.
+func parseGitURL(remoteURL string) (repo, fragment string, err error) {
+  if scpGitRegexp.MatchString(remoteURL) {
+    parts := strings.SplitN(remoteURL, "#", 2)
+    if len(parts) > 1 {
+      fragment = parts[1]
+    }
+    return parts[0], fragment, nil
+  }
+
+  if !strings.HasPrefix(remoteURL, localGitPrefix) && !urlutil.IsGitTransport(remoteURL) {
+    remoteURL = "https://" + remoteURL
+  }
+  u, err := url.Parse(remoteURL)
+  if err != nil {
+    return "", "", err
+  }
+  fragment = u.Fragment
+  u.Fragment = ""
+  return u.String(), fragment, nil
+}
+
+// cloneArgs returns the arguments of the git command cloning repo into
+// root. The clone is shallow when no ref is requested and the server
+// supports it.
+func cloneArgs(repo, fragment, root string) []string {
+  args := []string{"clone", "--recursive"}
+  shallow := len(fragment) == 0
+
+  if shallow && strings.HasPrefix(repo, "http") {
+    res, err := http.Head(fmt.Sprintf("%s/info/refs?service=git-upload-pack", repo))
+    if err != nil {
+      shallow = false
+    } else {
+      res.Body.Close()
+      if res.Header.Get("Content-Type") != "application/x-git-upload-pack-advertisement" {
+        shallow = false
+      }
+    }
+  }
+
+  if shallow && !strings.HasPrefix(repo, localGitPrefix) {
+    args = append(args, "--depth", "1")
+  }
+  return append(args, repo, root)
+}
+
+// checkoutGit checks out the ref of fragment in the clone at root and
+// returns the context directory it selects.
+func checkoutGit(fragment, root string) (string, error) {
+  refAndDir := strings.SplitN(fragment, ":", 2)
+
+  if len(refAndDir[0]) != 0 {
+    if output, err := gitWithinDir(root, "checkout", refAndDir[0]); err != nil {
+      return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
+    }
+  }
+
+  contextDir := root
+  if len(refAndDir) > 1 && len(refAndDir[1]) != 0 {
+    newCtx, err := symlink.FollowSymlinkInScope(filepath.Join(root, refAndDir[1]), root)
+    if err != nil {
+      return "", fmt.Errorf("Error setting git context, %q not within git root: %s", refAndDir[1], err)
+    }
+
+    fi, err := os.Stat(newCtx)
+    if err != nil {
+      return "", err
+    }
+    if !fi.IsDir() {
+      return "", fmt.Errorf("Error setting git context, not a directory: %s", newCtx)
+    }
+    contextDir = newCtx
+  }
+
+  return contextDir, nil
+}
+
+func gitWithinDir(dir string, args ...string) ([]byte, error) {
+  a := []string{"--work-tree", dir, "--git-dir", filepath.Join(dir, ".git")}
+  return git(append(a, args...)...)
+}
+
+func git(args ...string) ([]byte, error) {
+  return exec.Command("git", args...).CombinedOutput()
+}
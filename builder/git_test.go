@@ -0,0 +1,150 @@
+package builder
+
+import (
+  "io/ioutil"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "testing"
+)
+
+// createTestGitRepo creates a git repository whose master branch holds an
+// Enginefile in the engine sub directory and whose v2 branch holds a
+// different one. It returns the file:// URL of the repository.
+func createTestGitRepo(t *testing.T, dir string) string {
+  repo := createTestTempSubdir(t, dir, "builder-git-repo")
+  run := func(args ...string) {
+    args = append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+    if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+      t.Fatalf("git %v: %v (%s)", args, err, output)
+    }
+  }
+
+  run("init", "-q")
+  run("checkout", "-q", "-b", "master")
+  if err := os.Mkdir(filepath.Join(repo, "engine"), 0755); err != nil {
+    t.Fatal(err)
+  }
+  createTestTempFile(t, filepath.Join(repo, "engine"), DefaultEnginefileName, enginefileContents, 0644)
+  run("add", ".")
+  run("commit", "-q", "-m", "master")
+  run("checkout", "-q", "-b", "v2")
+  createTestTempFile(t, filepath.Join(repo, "engine"), DefaultEnginefileName, "FROM scratch", 0644)
+  run("commit", "-q", "-a", "-m", "v2")
+  run("checkout", "-q", "master")
+  return "file://" + repo
+}
+
+// withTempDir makes the temporary directories of the test be created in a
+// directory of their own, so that leftovers can be found.
+func withTempDir(t *testing.T) (string, func()) {
+  dir, cleanup := createTestTempDir(t, "", "builder-git-test")
+  oldTmpDir := os.Getenv("TMPDIR")
+  os.Setenv("TMPDIR", dir)
+  return dir, func() {
+    os.Setenv("TMPDIR", oldTmpDir)
+    cleanup()
+  }
+}
+
+func assertNoClone(t *testing.T, dir string) {
+  clones, err := filepath.Glob(filepath.Join(dir, "providence-build-git*"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(clones) != 0 {
+    t.Fatalf("expected the clones to be removed, found %v", clones)
+  }
+}
+
+func TestGetContextFromGitURLSubdir(t *testing.T) {
+  if _, err := exec.LookPath("git"); err != nil {
+    t.Skip("git is required")
+  }
+  dir, cleanup := withTempDir(t)
+  defer cleanup()
+  repoURL := createTestGitRepo(t, dir)
+
+  cloneDir, absContextDir, relEnginefile, err := GetContextFromGitURL(repoURL+"#:engine", "")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if absContextDir != filepath.Join(cloneDir, "engine") {
+    t.Fatalf("expected the context directory to be the engine directory of %s, got %s", cloneDir, absContextDir)
+  }
+  if relEnginefile != DefaultEnginefileName {
+    t.Fatalf("expected %s, got %s", DefaultEnginefileName, relEnginefile)
+  }
+
+  if err := os.RemoveAll(cloneDir); err != nil {
+    t.Fatal(err)
+  }
+  assertNoClone(t, dir)
+}
+
+func TestGetContextFromGitURLRef(t *testing.T) {
+  if _, err := exec.LookPath("git"); err != nil {
+    t.Skip("git is required")
+  }
+  dir, cleanup := withTempDir(t)
+  defer cleanup()
+  repoURL := createTestGitRepo(t, dir)
+
+  cloneDir, absContextDir, relEnginefile, err := GetContextFromGitURL(repoURL+"#v2:engine", "")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(cloneDir)
+
+  content, err := ioutil.ReadFile(filepath.Join(absContextDir, relEnginefile))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if string(content) != "FROM scratch" {
+    t.Fatalf("expected the Enginefile of the v2 branch, got %q", content)
+  }
+}
+
+func TestGetContextFromGitURLCleansUpOnError(t *testing.T) {
+  if _, err := exec.LookPath("git"); err != nil {
+    t.Skip("git is required")
+  }
+  dir, cleanup := withTempDir(t)
+  defer cleanup()
+  repoURL := createTestGitRepo(t, dir)
+
+  for _, url := range []string{
+    // No Enginefile at the root of the repository
+    repoURL,
+    repoURL + "#missing-ref",
+    repoURL + "#:missing-dir",
+    repoURL + "#:engine/" + DefaultEnginefileName,
+  } {
+    if _, _, _, err := GetContextFromGitURL(url, ""); err == nil {
+      t.Fatalf("expected an error for %s", url)
+    }
+    assertNoClone(t, dir)
+  }
+}
+
+func TestParseGitURL(t *testing.T) {
+  cases := []struct {
+    url, repo, fragment string
+  }{
+    {"github.com/org/repo.git", "https://github.com/org/repo.git", ""},
+    {"https://github.com/org/repo.git#v2:engine", "https://github.com/org/repo.git", "v2:engine"},
+    {"git://github.com/org/repo.git#v2", "git://github.com/org/repo.git", "v2"},
+    {"git@github.com:org/repo.git", "git@github.com:org/repo.git", ""},
+    {"git@github.com:org/repo.git#v2:engine", "git@github.com:org/repo.git", "v2:engine"},
+    {"file:///tmp/repo#:engine", "file:///tmp/repo", ":engine"},
+  }
+  for _, c := range cases {
+    repo, fragment, err := parseGitURL(c.url)
+    if err != nil {
+      t.Fatalf("%s: %v", c.url, err)
+    }
+    if repo != c.repo || fragment != c.fragment {
+      t.Fatalf("%s: expected %q and %q, got %q and %q", c.url, c.repo, c.fragment, repo, fragment)
+    }
+  }
+}
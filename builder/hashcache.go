@@ -0,0 +1,147 @@
+package builder
+
+import (
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "io"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+)
+
+// HashCache remembers the content hashes of local files, keyed by their
+// absolute path, so that unchanged files aren't read again. A file is
+// considered unchanged while its size and modification time are the same.
+type HashCache struct {
+  path    string
+  entries map[string]hashCacheEntry
+  visited map[string]bool
+  dirty   bool
+}
+
+type hashCacheEntry struct {
+  Size    int64  `json:"size"`
+  ModTime int64  `json:"mtime"`
+  Hash    string `json:"hash"`
+}
+
+// NewHashCache returns an empty cache saved to path.
+func NewHashCache(path string) *HashCache {
+  return &HashCache{
+    path:    path,
+    entries: map[string]hashCacheEntry{},
+    visited: map[string]bool{},
+  }
+}
+
+// LoadHashCache reads the cache saved at path. A missing file gives an
+// empty cache.
+func LoadHashCache(path string) (*HashCache, error) {
+  cache := NewHashCache(path)
+  content, err := ioutil.ReadFile(path)
+  if err != nil {
+    if os.IsNotExist(err) {
+      return cache, nil
+    }
+    return cache, err
+  }
+  if err := json.Unmarshal(content, &cache.entries); err != nil {
+    return NewHashCache(path), err
+  }
+  return cache, nil
+}
+
+// Hash returns the hash of the regular file at path, in the form
+// "sha256:<hex>". fi is the result of os.Lstat on path.
+func (c *HashCache) Hash(path string, fi os.FileInfo) (string, error) {
+  c.visited[path] = true
+  modTime := fi.ModTime().UnixNano()
+  if entry, ok := c.entries[path]; ok && entry.Size == fi.Size() && entry.ModTime == modTime {
+    return entry.Hash, nil
+  }
+
+  hash, err := hashFile(path)
+  if err != nil {
+    return "", err
+  }
+  c.entries[path] = hashCacheEntry{Size: fi.Size(), ModTime: modTime, Hash: hash}
+  c.dirty = true
+  return hash, nil
+}
+
+// Prune forgets the files under root which weren't hashed since the cache
+// was loaded, and the files which no longer exist wherever they are, such
+// as those of removed temporary build contexts.
+func (c *HashCache) Prune(root string) {
+  prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+  for path := range c.entries {
+    if c.visited[path] {
+      continue
+    }
+    if strings.HasPrefix(path, prefix) {
+      delete(c.entries, path)
+      c.dirty = true
+    } else if _, err := os.Lstat(path); os.IsNotExist(err) {
+      delete(c.entries, path)
+      c.dirty = true
+    }
+  }
+}
+
+// Save writes the cache to its file if it changed. The file is replaced
+// atomically so that concurrent builds never read a truncated cache.
+func (c *HashCache) Save() error {
+  if !c.dirty {
+    return nil
+  }
+  content, err := json.Marshal(c.entries)
+  if err != nil {
+    return err
+  }
+  dir := filepath.Dir(c.path)
+  if err := os.MkdirAll(dir, 0700); err != nil {
+    return err
+  }
+
+  temp, err := ioutil.TempFile(dir, filepath.Base(c.path))
+  if err != nil {
+    return err
+  }
+  _, err = temp.Write(content)
+  temp.Close()
+  if err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+
+  if err := os.Chmod(temp.Name(), 0600); err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+  if err := os.Rename(temp.Name(), c.path); err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+  c.dirty = false
+  return nil
+}
+
+func hashFile(path string) (string, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return "", err
+  }
+  defer f.Close()
+  return digest(f)
+}
+
+// digest returns the hash of the content of r in the form "sha256:<hex>".
+func digest(r io.Reader) (string, error) {
+  h := sha256.New()
+  if _, err := io.Copy(h, r); err != nil {
+    return "", err
+  }
+  return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
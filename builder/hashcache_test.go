@@ -0,0 +1,107 @@
+package builder
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+  "time"
+)
+
+func TestHashCache(t *testing.T) {
+  dir, cleanup := createTestTempDir(t, "", "builder-hashcache-test")
+  defer cleanup()
+  file := createTestTempFile(t, dir, "model.json", "{}", 0644)
+  mtime := time.Unix(1500000000, 0)
+  if err := os.Chtimes(file, mtime, mtime); err != nil {
+    t.Fatal(err)
+  }
+  fi, err := os.Lstat(file)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  cachePath := filepath.Join(dir, "cache", "hashes.json")
+  cache := NewHashCache(cachePath)
+  hash, err := cache.Hash(file, fi)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if expected := "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"; hash != expected {
+    t.Fatalf("expected %s, got %s", expected, hash)
+  }
+  if err := cache.Save(); err != nil {
+    t.Fatal(err)
+  }
+  saved, err := ioutil.ReadDir(filepath.Dir(cachePath))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(saved) != 1 || saved[0].Name() != "hashes.json" || saved[0].Mode().Perm() != 0600 {
+    t.Fatalf("expected only the cache file with 0600 permissions, got %v", saved)
+  }
+
+  // A file with the same size and modification time isn't read again
+  if err := ioutil.WriteFile(file, []byte("[]"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  if err := os.Chtimes(file, mtime, mtime); err != nil {
+    t.Fatal(err)
+  }
+  cache, err = LoadHashCache(cachePath)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if cached, err := cache.Hash(file, fi); err != nil || cached != hash {
+    t.Fatalf("expected the cached hash %s, got %s (%v)", hash, cached, err)
+  }
+
+  if err := os.Chtimes(file, mtime.Add(time.Second), mtime.Add(time.Second)); err != nil {
+    t.Fatal(err)
+  }
+  if fi, err = os.Lstat(file); err != nil {
+    t.Fatal(err)
+  }
+  if rehashed, err := cache.Hash(file, fi); err != nil || rehashed == hash {
+    t.Fatalf("expected the file to be hashed again, got %s (%v)", rehashed, err)
+  }
+}
+
+func TestHashCachePruneRemovedFiles(t *testing.T) {
+  dir, cleanup := createTestTempDir(t, "", "builder-hashcache-test")
+  defer cleanup()
+  // Each build from a git URL uses a new temporary clone
+  oldClone := createTestTempSubdir(t, dir, "clone")
+  newClone := createTestTempSubdir(t, dir, "clone")
+  kept := createTestTempSubdir(t, dir, "context")
+
+  cache := NewHashCache(filepath.Join(dir, "hashes.json"))
+  for _, root := range []string{oldClone, kept} {
+    file := createTestTempFile(t, root, "model.json", "{}", 0644)
+    fi, err := os.Lstat(file)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if _, err := cache.Hash(file, fi); err != nil {
+      t.Fatal(err)
+    }
+  }
+  if err := cache.Save(); err != nil {
+    t.Fatal(err)
+  }
+  if err := os.RemoveAll(oldClone); err != nil {
+    t.Fatal(err)
+  }
+
+  cache, err := LoadHashCache(filepath.Join(dir, "hashes.json"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  cache.Prune(newClone)
+  if _, ok := cache.entries[filepath.Join(oldClone, "model.json")]; ok {
+    t.Fatal("expected the files of the removed clone to be forgotten")
+  }
+  if _, ok := cache.entries[filepath.Join(kept, "model.json")]; !ok {
+    t.Fatal("expected the files of other contexts to be kept")
+  }
+}
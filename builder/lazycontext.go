@@ -0,0 +1,103 @@
+package builder
+
+import (
+  "path/filepath"
+  "strings"
+  "sync"
+)
+
+// lazyContext wraps a Context to return HashedFileInfo for its regular
+// files. A file is only hashed the first time it is returned by Stat or
+// Walk, unlike a tarsum which hashes the whole tree up front.
+type lazyContext struct {
+  ModifiableContext
+
+  mu   sync.Mutex
+  sums map[string]string
+}
+
+// NewLazyContext returns a Context whose Stat and Walk return a
+// *HashedFileInfo holding the "sha256:<hex>" hash of regular files.
+func NewLazyContext(context ModifiableContext) ModifiableContext {
+  return &lazyContext{ModifiableContext: context, sums: map[string]string{}}
+}
+
+// Stat returns the FileInfo of the file path resolves to with its hash.
+func (c *lazyContext) Stat(path string) (string, FileInfo, error) {
+  rel, fi, err := c.ModifiableContext.Stat(path)
+  if err != nil {
+    return "", nil, err
+  }
+  hashed, err := c.hashed(rel, fi)
+  if err != nil {
+    return "", nil, err
+  }
+  return rel, hashed, nil
+}
+
+// Walk walks the tree under root like the wrapped Context, hashing the
+// regular files as they are visited.
+func (c *lazyContext) Walk(root string, walkFn WalkFunc) error {
+  return c.ModifiableContext.Walk(root, func(path string, fi FileInfo, err error) error {
+    if err != nil {
+      return walkFn(path, fi, err)
+    }
+    hashed, err := c.hashed(path, fi)
+    if err != nil {
+      return walkFn(path, fi, err)
+    }
+    return walkFn(path, hashed, nil)
+  })
+}
+
+// Remove deletes path and forgets the hashes of the files under it.
+func (c *lazyContext) Remove(path string) error {
+  rel, _, err := c.ModifiableContext.Stat(path)
+  if err != nil {
+    return err
+  }
+  if err := c.ModifiableContext.Remove(path); err != nil {
+    return err
+  }
+
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  prefix := rel + string(filepath.Separator)
+  for p := range c.sums {
+    if p == rel || rel == "." || strings.HasPrefix(p, prefix) {
+      delete(c.sums, p)
+    }
+  }
+  return nil
+}
+
+func (c *lazyContext) hashed(rel string, fi FileInfo) (*HashedFileInfo, error) {
+  hashed := &HashedFileInfo{FileInfo: fi}
+  if !fi.Mode().IsRegular() {
+    return hashed, nil
+  }
+
+  c.mu.Lock()
+  sum, ok := c.sums[rel]
+  c.mu.Unlock()
+  if !ok {
+    var err error
+    if sum, err = c.hash(rel); err != nil {
+      return nil, err
+    }
+    c.mu.Lock()
+    c.sums[rel] = sum
+    c.mu.Unlock()
+  }
+  hashed.SetHash(sum)
+  return hashed, nil
+}
+
+func (c *lazyContext) hash(rel string) (string, error) {
+  r, err := c.ModifiableContext.Open(rel)
+  if err != nil {
+    return "", err
+  }
+  defer r.Close()
+  return digest(r)
+}
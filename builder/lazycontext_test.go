@@ -0,0 +1,54 @@
+package builder
+
+import (
+  "path/filepath"
+  "testing"
+)
+
+func TestLazyContext(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+
+  dir, err := NewDirectoryContext(contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  c := NewLazyContext(dir)
+
+  _, fi, err := c.Stat("escape")
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected := "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"
+  if hash := fi.(Hashed).Hash(); hash != expected {
+    t.Fatalf("expected %s, got %s", expected, hash)
+  }
+
+  hashes := map[string]string{}
+  err = c.Walk("weights", func(path string, fi FileInfo, err error) error {
+    if err != nil {
+      return err
+    }
+    hashes[filepath.ToSlash(path)] = fi.(Hashed).Hash()
+    return nil
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(hashes) != 2 || hashes["weights"] != "" || hashes["weights/a.bin"] == "" {
+    t.Fatalf("expected only weights/a.bin to be hashed, got %v", hashes)
+  }
+
+  // The hash of a file is computed once, until the file is removed
+  createTestTempFile(t, contextDir, "model.json", "[]", 0644)
+  if _, fi, err = c.Stat("model.json"); err != nil || fi.(Hashed).Hash() != expected {
+    t.Fatalf("expected the memoized hash, got %v (%v)", fi, err)
+  }
+  if err := c.Remove("model.json"); err != nil {
+    t.Fatal(err)
+  }
+  createTestTempFile(t, contextDir, "model.json", "[]", 0644)
+  if _, fi, err = c.Stat("model.json"); err != nil || fi.(Hashed).Hash() == expected {
+    t.Fatalf("expected model.json to be hashed again, got %v (%v)", fi, err)
+  }
+}
@@ -0,0 +1,130 @@
+package builder
+
+import (
+  "archive/tar"
+  "io"
+  "os"
+  "path/filepath"
+  "strings"
+  "time"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/docker/docker/pkg/archive"
+)
+
+// reproducibleTime is the modification time of every entry of a
+// reproducible build context.
+var reproducibleTime = time.Unix(0, 0).UTC()
+
+// ContextDigest returns the digest of the reproducible build context of the
+// files returned by HashContext, in the form "sha256:<hex>". It is the
+// digest of the manifest of the entries of the tar stream returned by
+// ReproducibleTar, which the server computes from the uploaded context, so
+// it doesn't depend on the compression of the upload. No file is read, the
+// hashes of the files are used instead.
+func ContextDigest(contextDir string, files []*HashedFileInfo) (string, error) {
+  manifest := types.ContextManifest{Entries: []types.ContextManifestEntry{}}
+  for _, fi := range files {
+    hdr, err := reproducibleHeader(contextDir, fi)
+    if err != nil {
+      return "", err
+    }
+    if hdr != nil {
+      manifest.Entries = append(manifest.Entries, reproducibleEntry(hdr, fi.Hash()))
+    }
+  }
+  return manifest.Digest()
+}
+
+// ReproducibleTar returns the files returned by HashContext as a tar stream
+// which only depends on their paths and content: entries are sorted by
+// path, their timestamps are the Unix epoch, they belong to root and have
+// 0755 or 0644 permissions. Special files such as sockets and devices are
+// left out. The stream fails if a file changed since it was hashed, so that
+// it always matches ContextDigest.
+func ReproducibleTar(contextDir string, files []*HashedFileInfo, compression archive.Compression) (io.ReadCloser, error) {
+  pr, pw := io.Pipe()
+  go func() {
+    compressed, err := archive.CompressStream(pw, compression)
+    if err != nil {
+      pw.CloseWithError(err)
+      return
+    }
+    if err := writeReproducibleTar(compressed, contextDir, files); err != nil {
+      pw.CloseWithError(err)
+      return
+    }
+    pw.CloseWithError(compressed.Close())
+  }()
+  return pr, nil
+}
+
+func writeReproducibleTar(w io.Writer, contextDir string, files []*HashedFileInfo) error {
+  tw := tar.NewWriter(w)
+  for _, fi := range files {
+    hdr, err := reproducibleHeader(contextDir, fi)
+    if err != nil {
+      return err
+    }
+    if hdr == nil {
+      continue
+    }
+    if err := tw.WriteHeader(hdr); err != nil {
+      return err
+    }
+    if hdr.Typeflag == tar.TypeReg {
+      if err := copyHashedFile(tw, filepath.Join(contextDir, filepath.FromSlash(fi.Path())), hdr.Size, fi.Hash()); err != nil {
+        return err
+      }
+    }
+  }
+  return tw.Close()
+}
+
+// reproducibleHeader returns the tar header of a file of the reproducible
+// build context, or nil for special files, which are left out.
+func reproducibleHeader(contextDir string, fi *HashedFileInfo) (*tar.Header, error) {
+  hdr := &tar.Header{
+    Name:    fi.Path(),
+    Mode:    0644,
+    ModTime: reproducibleTime,
+  }
+  switch {
+  case fi.IsDir():
+    hdr.Typeflag = tar.TypeDir
+    hdr.Name += "/"
+    hdr.Mode = 0755
+  case fi.Mode()&os.ModeSymlink != 0:
+    linkname, err := os.Readlink(filepath.Join(contextDir, filepath.FromSlash(fi.Path())))
+    if err != nil {
+      return nil, err
+    }
+    hdr.Typeflag = tar.TypeSymlink
+    hdr.Linkname = filepath.ToSlash(linkname)
+    hdr.Mode = 0777
+  case fi.Mode().IsRegular():
+    hdr.Typeflag = tar.TypeReg
+    hdr.Size = fi.Size()
+    if fi.Mode()&0111 != 0 {
+      hdr.Mode = 0755
+    }
+  default:
+    return nil, nil
+  }
+  return hdr, nil
+}
+
+// reproducibleEntry returns the manifest entry of the file of a tar header
+// with the given content hash.
+func reproducibleEntry(hdr *tar.Header, hash string) types.ContextManifestEntry {
+  entry := types.ContextManifestEntry{
+    Path:     strings.TrimSuffix(hdr.Name, "/"),
+    Mode:     hdr.FileInfo().Mode(),
+    Linkname: hdr.Linkname,
+  }
+  if hdr.Typeflag == tar.TypeReg {
+    entry.Size = hdr.Size
+    entry.Hash = hash
+  }
+  return entry
+}
@@ -0,0 +1,120 @@
+package builder
+
+import (
+  "archive/tar"
+  "io"
+  "os"
+  "path/filepath"
+  "reflect"
+  "testing"
+  "time"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/docker/docker/pkg/archive"
+)
+
+func prepareReproducibleContext(t *testing.T, perm os.FileMode, mtime time.Time) (string, func()) {
+  contextDir, cleanup := createTestTempDir(t, "", "builder-reproducible-test")
+  if err := os.MkdirAll(filepath.Join(contextDir, "weights"), 0700); err != nil {
+    t.Fatal(err)
+  }
+  files := []string{
+    createTestTempFile(t, contextDir, DefaultEnginefileName, enginefileContents, perm),
+    createTestTempFile(t, contextDir, "model.json", "{}", perm),
+    createTestTempFile(t, contextDir, filepath.Join("weights", "a.bin"), "a", perm),
+    createTestTempFile(t, contextDir, "cache.tmp", "", perm),
+  }
+  for _, file := range files {
+    if err := os.Chtimes(file, mtime, mtime); err != nil {
+      t.Fatal(err)
+    }
+  }
+  return contextDir, cleanup
+}
+
+func hashReproducibleContext(t *testing.T, contextDir string) []*HashedFileInfo {
+  files, err := HashContext(contextDir, []string{"."}, []string{"*.tmp"}, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  return files
+}
+
+func TestContextDigestIsReproducible(t *testing.T) {
+  contextDir1, cleanup1 := prepareReproducibleContext(t, 0600, time.Unix(1500000000, 0))
+  defer cleanup1()
+  contextDir2, cleanup2 := prepareReproducibleContext(t, 0644, time.Unix(1600000000, 0))
+  defer cleanup2()
+
+  digest1, err := ContextDigest(contextDir1, hashReproducibleContext(t, contextDir1))
+  if err != nil {
+    t.Fatal(err)
+  }
+  digest2, err := ContextDigest(contextDir2, hashReproducibleContext(t, contextDir2))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if digest1 != digest2 {
+    t.Fatalf("expected the same digest, got %s and %s", digest1, digest2)
+  }
+
+  createTestTempFile(t, contextDir2, "model.json", "[]", 0644)
+  if digest3, err := ContextDigest(contextDir2, hashReproducibleContext(t, contextDir2)); err != nil || digest3 == digest1 {
+    t.Fatalf("expected the digest to change with the content, got %s (%v)", digest3, err)
+  }
+}
+
+func TestReproducibleTar(t *testing.T) {
+  contextDir, cleanup := prepareReproducibleContext(t, 0700, time.Now())
+  defer cleanup()
+
+  files := hashReproducibleContext(t, contextDir)
+  tarStream, err := ReproducibleTar(contextDir, files, archive.Gzip)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer tarStream.Close()
+  r, err := archive.DecompressStream(tarStream)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  var names []string
+  manifest := types.ContextManifest{Entries: []types.ContextManifestEntry{}}
+  tr := tar.NewReader(r)
+  for {
+    hdr, err := tr.Next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      t.Fatal(err)
+    }
+    names = append(names, hdr.Name)
+    if !hdr.ModTime.Equal(time.Unix(0, 0)) || hdr.Uid != 0 || hdr.Gid != 0 || hdr.Uname != "" {
+      t.Fatalf("expected the metadata of %s to be normalized, got %+v", hdr.Name, hdr)
+    }
+    if hdr.Mode != 0755 {
+      t.Fatalf("expected the permissions of %s to be 0755, got %o", hdr.Name, hdr.Mode)
+    }
+    hash, err := digest(tr)
+    if err != nil {
+      t.Fatal(err)
+    }
+    manifest.Entries = append(manifest.Entries, reproducibleEntry(hdr, hash))
+  }
+
+  expected := []string{DefaultEnginefileName, "model.json", "weights/", "weights/a.bin"}
+  if !reflect.DeepEqual(names, expected) {
+    t.Fatalf("expected %v, got %v", expected, names)
+  }
+
+  // The server finds the digest of the build context in its entries
+  expectedDigest, err := ContextDigest(contextDir, files)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if digest, err := manifest.Digest(); err != nil || digest != expectedDigest {
+    t.Fatalf("expected the digest %s, got %s (%v)", expectedDigest, digest, err)
+  }
+}
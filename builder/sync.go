@@ -0,0 +1,190 @@
+package builder
+
+import (
+  "archive/tar"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io"
+  "os"
+  "path"
+  "path/filepath"
+  "strings"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/docker/docker/pkg/archive"
+)
+
+// HashContext returns the files of the context directory sent to the server
+// given the includes and excludes, in walk order. Regular files are hashed,
+// using cache when it isn't nil. FilePath holds the slash separated path
+// relative to the context directory.
+func HashContext(contextDir string, includes, excludes []string, cache *HashCache) ([]*HashedFileInfo, error) {
+  var files []*HashedFileInfo
+  err := walkContext(contextDir, includes, excludes, func(relFilePath string, f os.FileInfo) error {
+    fi := &HashedFileInfo{FileInfo: PathFileInfo{FileInfo: f, FilePath: relFilePath}}
+    if f.Mode().IsRegular() {
+      absPath := filepath.Join(contextDir, filepath.FromSlash(relFilePath))
+      var hash string
+      var err error
+      if cache != nil {
+        hash, err = cache.Hash(absPath, f)
+      } else {
+        hash, err = hashFile(absPath)
+      }
+      if err != nil {
+        return err
+      }
+      fi.SetHash(hash)
+    }
+    files = append(files, fi)
+    return nil
+  })
+  if err != nil {
+    return nil, err
+  }
+  if cache != nil {
+    cache.Prune(contextDir)
+  }
+  return files, nil
+}
+
+// ContextHashes returns the distinct hashes of files.
+func ContextHashes(files []*HashedFileInfo) []string {
+  seen := map[string]bool{}
+  var hashes []string
+  for _, fi := range files {
+    if hash := fi.Hash(); hash != "" && !seen[hash] {
+      seen[hash] = true
+      hashes = append(hashes, hash)
+    }
+  }
+  return hashes
+}
+
+// SyncContext returns the build context uploaded for a context session: a
+// tar stream holding the manifest of files followed by the content of the
+// files whose hash is listed in missing.
+func SyncContext(contextDir string, files []*HashedFileInfo, missing []string, compression archive.Compression) (io.ReadCloser, error) {
+  manifest, err := contextManifest(contextDir, files)
+  if err != nil {
+    return nil, err
+  }
+  content, err := json.Marshal(manifest)
+  if err != nil {
+    return nil, err
+  }
+
+  wanted := map[string]bool{}
+  for _, hash := range missing {
+    wanted[hash] = true
+  }
+
+  pr, pw := io.Pipe()
+  go func() {
+    pw.CloseWithError(writeSyncContext(pw, contextDir, files, content, wanted, compression))
+  }()
+  return pr, nil
+}
+
+func writeSyncContext(w io.Writer, contextDir string, files []*HashedFileInfo, manifest []byte, wanted map[string]bool, compression archive.Compression) error {
+  compressed, err := archive.CompressStream(w, compression)
+  if err != nil {
+    return err
+  }
+  tw := tar.NewWriter(compressed)
+
+  if err := tw.WriteHeader(&tar.Header{
+    Name:     types.ContextManifestPath,
+    Mode:     0644,
+    Size:     int64(len(manifest)),
+    Typeflag: tar.TypeReg,
+  }); err != nil {
+    return err
+  }
+  if _, err := tw.Write(manifest); err != nil {
+    return err
+  }
+
+  for _, fi := range files {
+    hash := fi.Hash()
+    if !wanted[hash] {
+      continue
+    }
+    delete(wanted, hash)
+    if err := writeBlob(tw, filepath.Join(contextDir, filepath.FromSlash(fi.Path())), hash, fi.Size()); err != nil {
+      return err
+    }
+  }
+
+  if err := tw.Close(); err != nil {
+    return err
+  }
+  return compressed.Close()
+}
+
+func writeBlob(tw *tar.Writer, filePath, hash string, size int64) error {
+  if err := tw.WriteHeader(&tar.Header{
+    Name:     BlobPath(hash),
+    Mode:     0644,
+    Size:     size,
+    Typeflag: tar.TypeReg,
+  }); err != nil {
+    return err
+  }
+  return copyHashedFile(tw, filePath, size, hash)
+}
+
+// copyHashedFile copies the content of the file at filePath to w, checking
+// that it still has the size and hash it had when it was hashed. The
+// upload fails otherwise, rather than sending content which doesn't match
+// its hash.
+func copyHashedFile(w io.Writer, filePath string, size int64, hash string) error {
+  f, err := os.Open(filePath)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  h := sha256.New()
+  if _, err := io.CopyN(io.MultiWriter(w, h), f, size); err != nil {
+    if err == io.EOF {
+      return fmt.Errorf("%s changed while the build context was sent", filePath)
+    }
+    return err
+  }
+  if "sha256:"+hex.EncodeToString(h.Sum(nil)) != hash {
+    return fmt.Errorf("%s changed while the build context was sent", filePath)
+  }
+  return nil
+}
+
+// BlobPath returns the name of the file holding the content of hash in the
+// build context of a context session.
+func BlobPath(hash string) string {
+  return path.Join(types.ContextBlobsDir, strings.Replace(hash, ":", "/", 1))
+}
+
+func contextManifest(contextDir string, files []*HashedFileInfo) (*types.ContextManifest, error) {
+  manifest := &types.ContextManifest{Entries: []types.ContextManifestEntry{}}
+  for _, fi := range files {
+    entry := types.ContextManifestEntry{
+      Path: fi.Path(),
+      Mode: fi.Mode(),
+      Hash: fi.Hash(),
+    }
+    if fi.Mode().IsRegular() {
+      entry.Size = fi.Size()
+    }
+    if fi.Mode()&os.ModeSymlink != 0 {
+      linkname, err := os.Readlink(filepath.Join(contextDir, filepath.FromSlash(fi.Path())))
+      if err != nil {
+        return nil, err
+      }
+      entry.Linkname = linkname
+    }
+    manifest.Entries = append(manifest.Entries, entry)
+  }
+  return manifest, nil
+}
@@ -0,0 +1,34 @@
+package builder
+
+import (
+  "io/ioutil"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/docker/docker/pkg/archive"
+)
+
+func TestSyncContextFailsOnChangedFile(t *testing.T) {
+  contextDir, cleanup := createTestTempDir(t, "", "builder-sync-test")
+  defer cleanup()
+  file := createTestTempFile(t, contextDir, "model.json", "{}", 0644)
+
+  files, err := HashContext(contextDir, []string{"."}, nil, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := ioutil.WriteFile(file, []byte("[]"), 0644); err != nil {
+    t.Fatal(err)
+  }
+
+  buildCtx, err := SyncContext(contextDir, files, ContextHashes(files), archive.Uncompressed)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer buildCtx.Close()
+  _, err = ioutil.ReadAll(buildCtx)
+  if err == nil || !strings.Contains(err.Error(), filepath.Join(contextDir, "model.json")+" changed") {
+    t.Fatalf("expected an error for the changed file, got %v", err)
+  }
+}
@@ -0,0 +1,43 @@
+package builder
+
+import (
+  "io"
+  "io/ioutil"
+  "os"
+
+  "github.com/docker/docker/pkg/archive"
+)
+
+// tarContext is a Context backed by a tar stream unpacked in a temporary
+// directory.
+type tarContext struct {
+  *dirContext
+}
+
+// MakeTarContext unpacks tarStream, which may be compressed, in a temporary
+// directory and returns a Context for it. The directory is removed by Close.
+func MakeTarContext(tarStream io.Reader) (ModifiableContext, error) {
+  root, err := ioutil.TempDir("", "providence-builder")
+  if err != nil {
+    return nil, err
+  }
+
+  // The files belong to the current user whatever the ownership recorded
+  // in the stream
+  if err := archive.Untar(tarStream, root, &archive.TarOptions{NoLchown: true}); err != nil {
+    os.RemoveAll(root)
+    return nil, err
+  }
+
+  c, err := newDirContext(root)
+  if err != nil {
+    os.RemoveAll(root)
+    return nil, err
+  }
+  return &tarContext{dirContext: c}, nil
+}
+
+// Close removes the temporary directory.
+func (c *tarContext) Close() error {
+  return os.RemoveAll(c.root)
+}
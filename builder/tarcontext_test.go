@@ -0,0 +1,42 @@
+package builder
+
+import (
+  "os"
+  "path/filepath"
+  "testing"
+
+  "github.com/docker/docker/pkg/archive"
+)
+
+func TestMakeTarContext(t *testing.T) {
+  contextDir, cleanup := prepareDirContext(t)
+  defer cleanup()
+  // Untar refuses links pointing out of the destination
+  os.Remove(filepath.Join(contextDir, "escape"))
+
+  tarStream, err := archive.Tar(contextDir, archive.Gzip)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer tarStream.Close()
+
+  c, err := MakeTarContext(tarStream)
+  if err != nil {
+    t.Fatal(err)
+  }
+  rel, fi, err := c.Stat("latest")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if rel != filepath.Join("weights", "a.bin") || fi.Size() != 1 {
+    t.Fatalf("expected weights/a.bin, got %s (%d bytes)", rel, fi.Size())
+  }
+  root := c.(*tarContext).root
+
+  if err := c.Close(); err != nil {
+    t.Fatal(err)
+  }
+  if _, err := os.Stat(root); !os.IsNotExist(err) {
+    t.Fatalf("expected the temporary directory to be removed, got %v", err)
+  }
+}
@@ -8,9 +8,9 @@ import (
 )
 
 const (
-	dockerfileContents   = "FROM busybox"
-	dockerignoreFilename = ".dockerignore"
-	testfileContents     = "test"
+	enginefileContents = "FROM busybox"
+	provignoreFilename = ".provignore"
+	testfileContents   = "test"
 )
 
 // createTestTempDir creates a temporary directory for testing.
@@ -2,12 +2,18 @@ package command
 
 import (
   "errors"
+  "fmt"
   "io"
+  "net/http"
   "os"
+  "runtime"
 
-  "github.com/TopPano/providence-cli/api"
+  "github.com/TopPano/providence-cli/cli"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
   cliflags "github.com/TopPano/providence-cli/cli/flags"
   "github.com/TopPano/providence-cli/client"
+  "github.com/TopPano/providence-cli/pkg/sockets"
+  "github.com/TopPano/providence-cli/pkg/tlsconfig"
 )
 
 // Streams is an interface which exposes the standard input and output streams
@@ -20,10 +26,11 @@ type Streams interface {
 // ProvCli represents the providence command line client.
 // Instances of the client can be returned from NewProvCli.
 type ProvCli struct {
-  in      *InStream
-  out     *OutStream
-  err     io.Writer
-  client  client.APIClient
+  configFile  *cliconfig.ConfigFile
+  in          *InStream
+  out         *OutStream
+  err         io.Writer
+  client      client.APIClient
 }
 
 // Client returns the APIClient
@@ -46,15 +53,19 @@ func (cli *ProvCli) In() *InStream {
   return cli.in
 }
 
+// ConfigFile returns the ConfigFile
+func (cli *ProvCli) ConfigFile() *cliconfig.ConfigFile {
+  return cli.configFile
+}
+
 // Initialize the ProvCli runs initialization that must happen after command
 // line flags are parsed.
 func (cli *ProvCli) Initialize(opts *cliflags.ClientOptions) error {
+  cli.configFile = LoadDefaultConfigFile(cli.err)
+
   var err error
-  cli.client, err = NewAPIClientFromFlags(opts.Common)
-  if err != nil {
-    return err
-  }
-  return nil
+  cli.client, err = NewAPIClientFromFlags(opts.Common, cli.configFile)
+  return err
 }
 
 // NewProvCli returns a ProvCli instance with IO output and error streams set by in, out and err.
@@ -62,29 +73,107 @@ func NewProvCli(in io.ReadCloser, out, err io.Writer) *ProvCli {
   return &ProvCli{in: NewInStream(in), out: NewOutStream(out), err: err}
 }
 
+// NewProvCliWithClient returns a ProvCli instance which uses the given
+// APIClient and configuration instead of initializing them from the command
+// line flags. It is mainly useful to run commands in tests.
+func NewProvCliWithClient(in io.ReadCloser, out, err io.Writer, apiClient client.APIClient, configFile *cliconfig.ConfigFile) *ProvCli {
+  cli := NewProvCli(in, out, err)
+  cli.client = apiClient
+  cli.configFile = configFile
+  return cli
+}
+
+// LoadDefaultConfigFile attempts to load the default config file and returns
+// an initialized ConfigFile struct if none is found.
+func LoadDefaultConfigFile(err io.Writer) *cliconfig.ConfigFile {
+  configFile, e := cliconfig.Load(cliconfig.Dir())
+  if e != nil {
+    fmt.Fprintf(err, "WARNING: Error loading config file: %v\n", e)
+  }
+  return configFile
+}
+
 // NewAPIClientFromFlags creates a new APIClient from command line flags
-func NewAPIClientFromFlags(opts *cliflags.CommonOptions) (client.APIClient, error) {
-  host, err := getServerHost(opts.Hosts)
+func NewAPIClientFromFlags(opts *cliflags.CommonOptions, configFile *cliconfig.ConfigFile) (client.APIClient, error) {
+  host, err := getServerHost(opts.Hosts, configFile)
+  if err != nil {
+    return &client.Client{}, err
+  }
+
+  httpClient, err := newHTTPClient(host, opts.TLSOptions)
   if err != nil {
     return &client.Client{}, err
   }
 
   customHeaders := map[string]string{}
+  for k, v := range configFile.HTTPHeaders {
+    customHeaders[k] = v
+  }
 
   customHeaders["User-Agent"] = UserAgent()
 
-  verStr := api.DefaultVersion
-  if tmpStr := os.Getenv("PROVIDENCE_API_VERSION"); tmpStr != "" {
-    verStr = tmpStr
+  retryPolicy := client.DefaultRetryPolicy
+  retryPolicy.MaxRetries = configFile.MaxRetries
+  if opts.MaxRetries != nil {
+    retryPolicy.MaxRetries = *opts.MaxRetries
+  }
+
+  return client.NewClientWithOpts(
+    client.WithHost(host),
+    client.WithHTTPClient(httpClient),
+    client.WithHTTPHeaders(customHeaders),
+    client.WithVersion(explicitAPIVersion(configFile)),
+    client.WithRetryPolicy(retryPolicy),
+    client.WithAPIVersionNegotiation(),
+  )
+}
+
+// explicitAPIVersion returns the API version pinned by users through the
+// PROVIDENCE_API_VERSION environment variable or the config file, if any.
+func explicitAPIVersion(configFile *cliconfig.ConfigFile) string {
+  if v := os.Getenv("PROVIDENCE_API_VERSION"); v != "" {
+    return v
+  }
+  return configFile.APIVersion
+}
+
+func newHTTPClient(host string, tlsOptions *tlsconfig.Options) (*http.Client, error) {
+  if tlsOptions == nil {
+    // let the api client configure the default transport.
+    return nil, nil
+  }
+
+  config, err := tlsconfig.Client(*tlsOptions)
+  if err != nil {
+    return nil, err
+  }
+  tr := &http.Transport{
+    TLSClientConfig: config,
+  }
+  proto, addr, _, err := client.ParseHost(host)
+  if err != nil {
+    return nil, err
+  }
+
+  if err := sockets.ConfigureTransport(tr, proto, addr); err != nil {
+    return nil, err
   }
 
-  return client.NewClient(host, verStr, nil, customHeaders)
+  return &http.Client{
+    Transport: tr,
+  }, nil
 }
 
-func getServerHost(hosts []string) (host string, err error) {
+func getServerHost(hosts []string, configFile *cliconfig.ConfigFile) (host string, err error) {
   switch len(hosts) {
   case 0:
     host = os.Getenv("PROVIDENCE_HOST")
+    if host == "" {
+      host = configFile.Host
+    }
+    if host == "" {
+      host = client.DefaultProvidenceHost
+    }
   case 1:
     host = hosts[0]
   default:
@@ -96,5 +185,5 @@ func getServerHost(hosts []string) (host string, err error) {
 
 // UserAgent returns the user agent string used for making API requests.
 func UserAgent() string {
-  return "Providence-Client/"
+  return "Providence-Client/" + cli.Version + " (" + runtime.GOOS + ")"
 }
@@ -3,6 +3,7 @@ package commands
 import (
   "github.com/TopPano/providence-cli/cli/command"
   "github.com/TopPano/providence-cli/cli/command/engine"
+  "github.com/TopPano/providence-cli/cli/command/system"
   "github.com/dnephin/cobra"
 )
 
@@ -10,5 +11,6 @@ import (
 func AddCommands(cmd *cobra.Command, provCli *command.ProvCli) {
   cmd.AddCommand(
     engine.NewEngineCommand(provCli),
+    system.NewVersionCommand(provCli),
   )
 }
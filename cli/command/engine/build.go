@@ -2,36 +2,66 @@ package engine
 
 import (
   "bytes"
+  "encoding/json"
   "fmt"
   "io"
+  "io/ioutil"
   "os"
   "path/filepath"
+  "time"
 
   "golang.org/x/net/context"
 
+  "github.com/Sirupsen/logrus"
+  "github.com/TopPano/providence-cli/api/errdefs"
   "github.com/TopPano/providence-cli/api/types"
   "github.com/TopPano/providence-cli/builder"
+  "github.com/TopPano/providence-cli/builder/enginefile"
   "github.com/TopPano/providence-cli/builder/provignore"
   "github.com/TopPano/providence-cli/cli"
   "github.com/TopPano/providence-cli/cli/command"
+  "github.com/TopPano/providence-cli/opts"
+  "github.com/TopPano/providence-cli/reference"
   "github.com/docker/docker/pkg/archive"
+  "github.com/docker/docker/pkg/fileutils"
   "github.com/docker/docker/pkg/jsonmessage"
   "github.com/docker/docker/pkg/progress"
   "github.com/docker/docker/pkg/streamformatter"
   "github.com/docker/docker/pkg/urlutil"
+  units "github.com/docker/go-units"
   "github.com/dnephin/cobra"
 )
 
 type buildOptions struct {
   context         string
   enginefileName  string
+  tags            opts.ListOpts
+  buildArgs       opts.ListOpts
   quiet           bool
   compress        bool
+  progress        string
+  iidFile         string
+  metadataFile    string
+  check           bool
+  minimalContext  bool
+  sync            bool
+  reproducible    bool
+
+  // localContextFlags are the flags set by users which only apply to local
+  // context directories and git repositories.
+  localContextFlags []string
 }
 
+// localContextFlags are the boolean flags which only apply to local context
+// directories and git repositories.
+var localContextFlags = []string{"minimal-context", "reproducible", "sync"}
+
 // NewBuildCommand creates a new `prov engine build` command
 func NewBuildCommand(provCli *command.ProvCli) *cobra.Command {
-  options := buildOptions{}
+  options := buildOptions{
+    tags:      opts.NewListOpts(validateTag),
+    buildArgs: opts.NewListOpts(opts.ValidateEnv),
+  }
 
   cmd := &cobra.Command{
     Use:    "build [OPTIONS] PATH | URL | -",
@@ -39,19 +69,92 @@ func NewBuildCommand(provCli *command.ProvCli) *cobra.Command {
     Args:   cli.ExactArgs(1),
     RunE:   func(cmd *cobra.Command, args []string) error {
       options.context = args[0]
+      for _, name := range localContextFlags {
+        if f := cmd.Flags().Lookup(name); f.Changed && f.Value.String() == "true" {
+          options.localContextFlags = append(options.localContextFlags, name)
+        }
+      }
       return runBuild(provCli, options)
     },
   }
 
   flags := cmd.Flags()
 
+  flags.VarP(&options.tags, "tag", "t", "Name and optionally a tag in the 'name:tag' format")
+  flags.SetAnnotation("tag", "version", []string{"1.1"})
+  flags.Var(&options.buildArgs, "build-arg", "Set build-time variables")
+  flags.SetAnnotation("build-arg", "version", []string{"1.1"})
   flags.StringVarP(&options.enginefileName, "file", "f", "", "Name of the Enginefile (Default is 'PATH/Enginefile')")
-  flags.BoolVarP(&options.compress, "quiet", "q", false, "Suppress the build output and print engine ID on success")
+  flags.BoolVarP(&options.quiet, "quiet", "q", false, "Suppress the build output and print engine ID on success")
   flags.BoolVar(&options.compress, "compress", true, "Compress the build context using gzip")
+  flags.StringVar(&options.progress, "progress", progressAuto, "Set type of progress output (auto, tty, plain, json, quiet)")
+  flags.StringVar(&options.iidFile, "iidfile", "", "Write the engine ID to the file")
+  flags.StringVar(&options.metadataFile, "metadata-file", "", "Write build result metadata to the file")
+  flags.BoolVar(&options.check, "check", false, "Check the Enginefile for errors without building it")
+  flags.BoolVar(&options.minimalContext, "minimal-context", false, "Only send the files of the context referenced by the Enginefile")
+  flags.BoolVar(&options.sync, "sync", false, "Only upload the files of the build context the server doesn't have")
+  flags.BoolVar(&options.reproducible, "reproducible", true, "Send a reproducible build context, which the server can reuse across builds")
 
   return cmd
 }
 
+// validateTag checks if the given engine name can be resolved.
+func validateTag(rawRepo string) (string, error) {
+  _, err := reference.ParseNamed(rawRepo)
+  if err != nil {
+    return "", err
+  }
+
+  return rawRepo, nil
+}
+
+// buildMetadata is the document written by `prov engine build --metadata-file`.
+// ContextDigest is always the digest of builder.ContextDigest, which only
+// depends on the paths and content of the files of the build context, so
+// it can be compared whatever the upload mode and compression. The context
+// sent to the server is the reproducible one it identifies, unless --sync
+// sends the same files. It is left out for contexts read from stdin or
+// downloaded from a URL, whose files aren't known locally.
+type buildMetadata struct {
+  EngineID      string             `json:"engineId"`
+  Tags          []string           `json:"tags"`
+  ContextDigest string             `json:"contextDigest,omitempty"`
+  Enginefile    string             `json:"enginefile"`
+  BuildArgs     map[string]*string `json:"buildArgs"`
+  DurationMs    int64              `json:"durationMs"`
+}
+
+// writeMetadataFile writes the metadata of a finished build to filename.
+func writeMetadataFile(filename string, metadata buildMetadata) error {
+  if metadata.Tags == nil {
+    metadata.Tags = []string{}
+  }
+  if metadata.BuildArgs == nil {
+    metadata.BuildArgs = map[string]*string{}
+  }
+  b, err := json.MarshalIndent(metadata, "", "  ")
+  if err != nil {
+    return err
+  }
+  if err := ioutil.WriteFile(filename, append(b, '\n'), 0666); err != nil {
+    return fmt.Errorf("failed to write metadata file: %v", err)
+  }
+  return nil
+}
+
+// normalizeTags returns the tags in their 'name:tag' form.
+func normalizeTags(tags []string) []string {
+  normalized := []string{}
+  for _, tag := range tags {
+    named, err := reference.ParseNamed(tag)
+    if err != nil {
+      continue
+    }
+    normalized = append(normalized, reference.TagNameOnly(named).String())
+  }
+  return normalized
+}
+
 // lastProgressOutput is the same as progress.Output except
 // that it only output with the last update. It is used in
 // non terminal scenarios to depress verbose messages.
@@ -68,17 +171,105 @@ func (out *lastProgressOutput) WriteProgress(prog progress.Progress) error {
   return out.output.WriteProgress(prog)
 }
 
+// readProvignore returns the exclude patterns of the .provignore file of
+// the context directory, if any.
+func readProvignore(contextDir string) ([]string, error) {
+  f, err := os.Open(filepath.Join(contextDir, ".provignore"))
+  if os.IsNotExist(err) {
+    return nil, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+  return provignore.ReadAll(f)
+}
+
+// minimalContextIncludes returns the files of the context directory used by
+// the Enginefile and reports how much smaller the context gets. It returns
+// nil when the whole context is needed.
+func minimalContextIncludes(out io.Writer, contextDir, relEnginefile string, excludes []string) []string {
+  f, err := os.Open(filepath.Join(contextDir, relEnginefile))
+  if err != nil {
+    fmt.Fprintf(out, "Sending the full build context: %v\n", err)
+    return nil
+  }
+  defer f.Close()
+
+  sources, err := enginefile.ContextSources(f, contextDir)
+  if err != nil {
+    fmt.Fprintf(out, "Sending the full build context: %v\n", err)
+    return nil
+  }
+  // Sources excluded by .provignore must stay out of the context
+  var includes []string
+  for _, source := range sources {
+    if excluded, _ := fileutils.Matches(source, excludes); !excluded {
+      includes = append(includes, source)
+    }
+  }
+  includes = append(includes, relEnginefile)
+  if _, err := os.Lstat(filepath.Join(contextDir, ".provignore")); err == nil {
+    includes = append(includes, ".provignore")
+  }
+
+  fullFiles, fullSize, err := builder.ContextSize(contextDir, []string{"."}, excludes)
+  if err != nil {
+    return includes
+  }
+  files, size, err := builder.ContextSize(contextDir, includes, excludes)
+  if err != nil {
+    return includes
+  }
+  var saved float64
+  if fullSize > 0 {
+    saved = float64(fullSize-size) / float64(fullSize) * 100
+  }
+  fmt.Fprintf(out, "Minimal build context: %d of %d files, %s of %s (%.1f%% smaller)\n",
+    files, fullFiles, units.HumanSize(float64(size)), units.HumanSize(float64(fullSize)), saved)
+  return includes
+}
+
+func isLocalDir(c string) bool {
+  _, err := os.Stat(c)
+  return err == nil
+}
+
 func runBuild(provCli *command.ProvCli, options buildOptions) error {
+  if err := validateProgressMode(options.progress); err != nil {
+    return err
+  }
+  if options.progress == progressQuiet {
+    options.quiet = true
+  }
+  progressMode := progressQuiet
+  if !options.quiet {
+    progressMode = resolveProgressMode(options.progress, provCli.Out().IsTerminal())
+  }
+  if options.iidFile != "" {
+    // Avoid leaving a stale file if the build fails
+    if err := os.Remove(options.iidFile); err != nil && !os.IsNotExist(err) {
+      return fmt.Errorf("failed to remove engine ID file: %v", err)
+    }
+  }
+  start := time.Now()
 
   var (
-    buildCtx  io.ReadCloser
-    err       error
+    buildCtx      io.ReadCloser
+    sessionID     string
+    files         []*builder.HashedFileInfo
+    compression   = archive.Uncompressed
+    contextDigest string
+    cachedContext bool
+    err           error
   )
+  ctx := context.Background()
 
   specifiedContext := options.context
 
   var (
     contextDir    string
+    tempDir       string
     relEnginefile string
     progBuff      io.Writer
     buildBuff     io.Writer
@@ -90,8 +281,24 @@ func runBuild(provCli *command.ProvCli, options buildOptions) error {
     progBuff = bytes.NewBuffer(nil)
     buildBuff = bytes.NewBuffer(nil)
   }
+  if progressMode == progressJSON {
+    // Keep the output a stream of JSON events
+    progBuff = provCli.Err()
+  }
+
+  switch {
+  case specifiedContext == "-":
+    buildCtx, relEnginefile, err = builder.GetContextFromReader(provCli.In(), options.enginefileName)
+  case isLocalDir(specifiedContext):
+    contextDir, relEnginefile, err = builder.GetContextFromLocalDir(specifiedContext, options.enginefileName)
+  case builder.IsGitURL(specifiedContext):
+    tempDir, contextDir, relEnginefile, err = builder.GetContextFromGitURL(specifiedContext, options.enginefileName)
+  case urlutil.IsURL(specifiedContext):
+    buildCtx, relEnginefile, err = builder.GetContextFromURL(progBuff, specifiedContext, options.enginefileName)
+  default:
+    return fmt.Errorf("unable to prepare context: path %q not found", specifiedContext)
+  }
 
-  contextDir, relEnginefile, err = builder.GetContextFromLocalDir(specifiedContext, options.enginefileName)
   if err != nil {
     if options.quiet && urlutil.IsURL(specifiedContext) {
       fmt.Fprintln(provCli.Err(), progBuff)
@@ -99,61 +306,180 @@ func runBuild(provCli *command.ProvCli, options buildOptions) error {
     return fmt.Errorf("unable to prepare context: %s", err)
   }
 
-  f, err := os.Open(filepath.Join(contextDir, ".provignore"))
-  if err != nil && !os.IsNotExist(err) {
-    return err
+  if tempDir != "" {
+    defer os.RemoveAll(tempDir)
   }
-  defer f.Close()
 
-  var excludes []string
-  if err == nil {
-    excludes, err = provignore.ReadAll(f)
-    if err != nil {
-      return err
+  if options.check {
+    if buildCtx != nil {
+      buildCtx.Close()
+      return fmt.Errorf("--check requires a local context directory or a git repository")
+    }
+    lintOpts := lintOptions{enginefileName: options.enginefileName, buildArgs: options.buildArgs}
+    if isLocalDir(specifiedContext) {
+      lintOpts.context = specifiedContext
     }
+    return lintEnginefile(provCli, contextDir, relEnginefile, lintOpts)
   }
 
-  if err := builder.ValidateContextDirectory(contextDir, excludes); err != nil {
-    return fmt.Errorf("Error checking context: '%s'.", err)
+  if len(options.localContextFlags) > 0 && buildCtx != nil {
+    buildCtx.Close()
+    return fmt.Errorf("--%s requires a local context directory or a git repository", options.localContextFlags[0])
   }
 
-  var includes = []string{"."}
+  if buildCtx == nil {
+    // And canonicalize enginefile name to a platform-independent one
+    relEnginefile, err = archive.CanonicalTarNameForPath(relEnginefile)
+    if err != nil {
+      return fmt.Errorf("cannot canonicalize enginefile path %s: %v", relEnginefile, err)
+    }
 
-  compression := archive.Uncompressed
-  if options.compress {
-    compression = archive.Gzip
-  }
-  buildCtx, err = archive.TarWithOptions(contextDir, &archive.TarOptions{
-    Compression:      compression,
-    ExcludePatterns:  excludes,
-    IncludeFiles:     includes,
-  })
-  if err != nil {
-    return err
-  }
+    excludes, err := readProvignore(contextDir)
+    if err != nil {
+      return err
+    }
 
-  ctx := context.Background()
+    if err := builder.ValidateContextDirectory(contextDir, excludes); err != nil {
+      return fmt.Errorf("Error checking context: '%s'.", err)
+    }
+
+    // If .provignore mentions .provignore or the Enginefile then make
+    // sure we send both files over to the server because the Enginefile
+    // is, obviously, needed no matter what, and .provignore is needed to
+    // know if either one needs to be removed. Ignore errors here, as they
+    // will have been caught by ValidateContextDirectory above.
+    var includes = []string{"."}
+    keepThem1, _ := fileutils.Matches(".provignore", excludes)
+    keepThem2, _ := fileutils.Matches(relEnginefile, excludes)
+    if keepThem1 || keepThem2 {
+      includes = append(includes, ".provignore", relEnginefile)
+    }
+    if options.minimalContext {
+      if minimal := minimalContextIncludes(progBuff, contextDir, relEnginefile, excludes); minimal != nil {
+        includes = minimal
+      }
+    }
+
+    if options.compress {
+      compression = archive.Gzip
+    }
+    if options.sync || options.reproducible || options.metadataFile != "" {
+      if files, err = hashContext(contextDir, includes, excludes, tempDir == ""); err != nil {
+        return err
+      }
+      if contextDigest, err = builder.ContextDigest(contextDir, files); err != nil {
+        return err
+      }
+    }
+    if options.sync {
+      buildCtx, sessionID, err = syncContext(ctx, provCli, progBuff, contextDir, files, compression)
+      if err != nil {
+        return err
+      }
+    }
+    if options.reproducible && buildCtx == nil {
+      fmt.Fprintf(progBuff, "Build context digest: %s\n", contextDigest)
+      // The upload is only skipped when the server answers, a server which
+      // can't tell gets the build context
+      if cachedContext, err = provCli.Client().ContextCached(ctx, contextDigest); err != nil {
+        logrus.Debugf("Failed to look up the cached build context: %v", err)
+      }
+      if cachedContext {
+        fmt.Fprintf(progBuff, "Using cached build context %s\n", contextDigest)
+      }
+    }
+    if buildCtx == nil && !cachedContext {
+      if contextDigest != "" {
+        // Send the build context the digest identifies
+        buildCtx, err = builder.ReproducibleTar(contextDir, files, compression)
+      } else {
+        buildCtx, err = archive.TarWithOptions(contextDir, &archive.TarOptions{
+          Compression:      compression,
+          ExcludePatterns:  excludes,
+          IncludeFiles:     includes,
+        })
+      }
+      if err != nil {
+        return err
+      }
+    }
+  }
 
   // Setup an upload progress bar
-  progressOutput := streamformatter.NewStreamFormatter().NewProgressOutput(progBuff, true)
+  var (
+    progressOutput = streamformatter.NewStreamFormatter().NewProgressOutput(progBuff, true)
+    jsonOutput     *jsonProgressOutput
+  )
+  switch progressMode {
+  case progressJSON:
+    jsonOutput = newJSONProgressOutput(provCli.Out())
+    progressOutput = jsonOutput
+  case progressPlain:
+    progressOutput = &lastProgressOutput{output: progressOutput}
+  }
 
-  var body io.Reader = progress.NewProgressReader(buildCtx, progressOutput, 0, "", "Sending build context to Providencer server")
+  var body io.Reader
+  if !cachedContext {
+    body = progress.NewProgressReader(buildCtx, progressOutput, 0, "", "Sending build context to Providencer server")
+  }
 
   buildOptions := types.EngineBuildOptions{
+    Tags:         options.tags.GetAll(),
     Enginefile:   relEnginefile,
+    BuildArgs:    opts.ConvertKVStringsToMapWithNil(options.buildArgs.GetAll()),
+    SessionID:    sessionID,
+  }
+  if cachedContext {
+    buildOptions.ContextDigest = contextDigest
   }
 
   response, err := provCli.Client().EngineBuild(ctx, body, buildOptions)
+  if err != nil && cachedContext && errdefs.IsNotFound(err) {
+    // The server dropped the build context since it was looked up
+    fmt.Fprintf(progBuff, "The server no longer holds build context %s, sending it\n", contextDigest)
+    if buildCtx, err = builder.ReproducibleTar(contextDir, files, compression); err != nil {
+      return err
+    }
+    body = progress.NewProgressReader(buildCtx, progressOutput, 0, "", "Sending build context to Providencer server")
+    buildOptions.ContextDigest = ""
+    response, err = provCli.Client().EngineBuild(ctx, body, buildOptions)
+  }
   if err != nil {
     if options.quiet {
       fmt.Fprintf(provCli.Err(), "%s", progBuff)
     }
+    if jsonOutput != nil {
+      jsonOutput.Finish("", err)
+    }
     return err
   }
 
   defer response.Body.Close()
 
-  err = jsonmessage.DisplayJSONMessagesStream(response.Body, buildBuff, provCli.Out().FD(), provCli.Out().IsTerminal(), nil)
+  var (
+    unusedBuildArgs []string
+    engineID        string
+  )
+  aux := func(auxJSON *json.RawMessage) {
+    var result types.BuildResult
+    if err := json.Unmarshal(*auxJSON, &result); err != nil {
+      fmt.Fprintf(provCli.Err(), "Failed to parse aux message: %s\n", err)
+      return
+    }
+    unusedBuildArgs = append(unusedBuildArgs, result.UnusedBuildArgs...)
+    if result.ID != "" {
+      engineID = result.ID
+    }
+  }
+
+  if jsonOutput != nil {
+    err = jsonOutput.DisplayStream(response.Body, aux)
+    if ferr := jsonOutput.Finish(engineID, err); err == nil {
+      err = ferr
+    }
+  } else {
+    err = jsonmessage.DisplayJSONMessagesStream(response.Body, buildBuff, provCli.Out().FD(), progressMode == progressTTY, aux)
+  }
   if err != nil {
     if jerr, ok := err.(*jsonmessage.JSONError); ok {
       // if no error code is set, default to 1
@@ -165,12 +491,40 @@ func runBuild(provCli *command.ProvCli, options buildOptions) error {
       }
       return cli.StatusError{Status: jerr.Message, StatusCode: jerr.Code}
     }
+    return err
   }
 
-  // Everything worked so if -q was provided the output from the server
-  // should be just the engine ID and we'll print that to stdout.
+  if len(unusedBuildArgs) > 0 {
+    fmt.Fprintf(provCli.Err(), "[Warning] One or more build-args %v were not consumed\n", unusedBuildArgs)
+  }
+
+  // Everything worked so if -q was provided print the engine ID to stdout,
+  // falling back to the output of servers that don't report it.
   if options.quiet {
-    fmt.Fprintf(provCli.Out(), "%s", buildBuff)
+    if engineID != "" {
+      fmt.Fprintln(provCli.Out(), engineID)
+    } else {
+      fmt.Fprintf(provCli.Out(), "%s", buildBuff)
+    }
+  }
+
+  if (options.iidFile != "" || options.metadataFile != "") && engineID == "" {
+    return fmt.Errorf("the server did not report the ID of the built engine")
+  }
+  if options.iidFile != "" {
+    if err := ioutil.WriteFile(options.iidFile, []byte(engineID), 0666); err != nil {
+      return fmt.Errorf("failed to write engine ID file: %v", err)
+    }
+  }
+  if options.metadataFile != "" {
+    return writeMetadataFile(options.metadataFile, buildMetadata{
+      EngineID:      engineID,
+      Tags:          normalizeTags(buildOptions.Tags),
+      ContextDigest: contextDigest,
+      Enginefile:    relEnginefile,
+      BuildArgs:     buildOptions.BuildArgs,
+      DurationMs:    int64(time.Since(start) / time.Millisecond),
+    })
   }
 
   return nil
@@ -0,0 +1,257 @@
+package engine
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/docker/docker/pkg/jsonmessage"
+  "github.com/docker/docker/pkg/progress"
+)
+
+// Progress modes accepted by `prov engine build --progress`
+const (
+  progressAuto  = "auto"
+  progressTTY   = "tty"
+  progressPlain = "plain"
+  progressJSON  = "json"
+  progressQuiet = "quiet"
+)
+
+var progressModes = []string{progressAuto, progressTTY, progressPlain, progressJSON, progressQuiet}
+
+// validateProgressMode checks that mode is one of the supported progress modes.
+func validateProgressMode(mode string) error {
+  for _, m := range progressModes {
+    if mode == m {
+      return nil
+    }
+  }
+  return fmt.Errorf("invalid progress mode %q: must be one of %s", mode, strings.Join(progressModes, ", "))
+}
+
+// resolveProgressMode turns the auto mode into tty or plain depending on
+// whether the output is a terminal.
+func resolveProgressMode(mode string, isTerminal bool) string {
+  if mode != progressAuto {
+    return mode
+  }
+  if isTerminal {
+    return progressTTY
+  }
+  return progressPlain
+}
+
+// buildEvent is a build progress event printed as a single line of JSON
+// by `prov engine build --progress=json`.
+type buildEvent struct {
+  // Time is the time the event was emitted.
+  Time time.Time `json:"time"`
+  // Type is one of "context", "step", "output", "status", "error" or "result".
+  Type string `json:"type"`
+  // Step is the number of the step the event belongs to, if any.
+  Step int `json:"step,omitempty"`
+  // TotalSteps is the number of steps of the build, if known.
+  TotalSteps int `json:"totalSteps,omitempty"`
+  // Instruction is the Enginefile instruction run by the step.
+  Instruction string `json:"instruction,omitempty"`
+  // Status is "started", "done" or "cached" for steps, and "success" or
+  // "failed" for the result. Other events carry the server status as is.
+  Status string `json:"status,omitempty"`
+  // Message is a line of output of the build.
+  Message string `json:"message,omitempty"`
+  // Progress is the progress of a transfer, such as the context upload.
+  Progress *buildEventProgress `json:"progress,omitempty"`
+  // Error is the error message of a failed build.
+  Error string `json:"error,omitempty"`
+  // DurationMs is the time spent on a finished step or on the whole build.
+  DurationMs int64 `json:"durationMs,omitempty"`
+  // Summary is only set on the result event.
+  Summary *buildSummary `json:"summary,omitempty"`
+}
+
+// buildEventProgress is the progress of a transfer.
+type buildEventProgress struct {
+  Current int64 `json:"current"`
+  Total   int64 `json:"total,omitempty"`
+}
+
+// buildSummary describes the outcome of a build.
+type buildSummary struct {
+  EngineID  string   `json:"engineId,omitempty"`
+  Tags      []string `json:"tags,omitempty"`
+  Steps     int      `json:"steps"`
+  CacheHits int      `json:"cacheHits"`
+}
+
+var stepRegexp = regexp.MustCompile(`^Step (\d+)/(\d+) : (.*)$`)
+
+// jsonProgressOutput writes the upload progress and the build output as
+// a stream of buildEvent, one per line.
+type jsonProgressOutput struct {
+  enc   *json.Encoder
+  now   func() time.Time
+  start time.Time
+
+  step      int
+  steps     int
+  stepStart time.Time
+  cached    bool
+
+  instruction string
+  totalSteps  int
+  cacheHits   int
+  engineID    string
+  tags        []string
+}
+
+func newJSONProgressOutput(out io.Writer) *jsonProgressOutput {
+  o := &jsonProgressOutput{enc: json.NewEncoder(out), now: time.Now}
+  o.start = o.now()
+  return o
+}
+
+func (o *jsonProgressOutput) emit(event buildEvent) error {
+  event.Time = o.now().UTC()
+  return o.enc.Encode(event)
+}
+
+// WriteProgress reports the progress of the build context upload.
+func (o *jsonProgressOutput) WriteProgress(prog progress.Progress) error {
+  event := buildEvent{Type: "context", Status: prog.Action, Message: prog.Message}
+  if prog.Message == "" {
+    event.Progress = &buildEventProgress{Current: prog.Current, Total: prog.Total}
+  }
+  return o.emit(event)
+}
+
+// DisplayStream converts the JSON messages of the build response into build
+// events. It returns a *jsonmessage.JSONError if the build failed.
+func (o *jsonProgressOutput) DisplayStream(in io.Reader, auxCallback func(*json.RawMessage)) error {
+  dec := json.NewDecoder(in)
+  for {
+    var jm jsonmessage.JSONMessage
+    if err := dec.Decode(&jm); err != nil {
+      if err == io.EOF {
+        return nil
+      }
+      return err
+    }
+
+    switch {
+    case jm.Aux != nil:
+      if auxCallback != nil {
+        auxCallback(jm.Aux)
+      }
+    case jm.Error != nil:
+      o.emit(buildEvent{Type: "error", Step: o.step, Error: jm.Error.Message})
+      return jm.Error
+    case jm.Stream != "":
+      for _, line := range strings.Split(strings.TrimRight(jm.Stream, "\n"), "\n") {
+        if err := o.streamLine(strings.TrimSpace(line)); err != nil {
+          return err
+        }
+      }
+    case jm.Status != "":
+      event := buildEvent{Type: "status", Step: o.step, Status: jm.Status}
+      if jm.Progress != nil && (jm.Progress.Current > 0 || jm.Progress.Total > 0) {
+        event.Progress = &buildEventProgress{Current: jm.Progress.Current, Total: jm.Progress.Total}
+      }
+      if err := o.emit(event); err != nil {
+        return err
+      }
+    }
+  }
+}
+
+func (o *jsonProgressOutput) streamLine(line string) error {
+  if line == "" {
+    return nil
+  }
+  if m := stepRegexp.FindStringSubmatch(line); m != nil {
+    if err := o.finishStep(); err != nil {
+      return err
+    }
+    o.step, _ = strconv.Atoi(m[1])
+    o.totalSteps, _ = strconv.Atoi(m[2])
+    o.instruction = m[3]
+    o.stepStart = o.now()
+    o.cached = false
+    o.steps++
+    return o.emit(buildEvent{Type: "step", Step: o.step, TotalSteps: o.totalSteps, Instruction: o.instruction, Status: "started"})
+  }
+
+  switch {
+  case line == "---> Using cache":
+    o.cached = true
+    o.cacheHits++
+    return nil
+  case strings.HasPrefix(line, "Successfully built "):
+    if o.engineID == "" {
+      o.engineID = strings.TrimPrefix(line, "Successfully built ")
+    }
+  case strings.HasPrefix(line, "Successfully tagged "):
+    o.tags = append(o.tags, strings.TrimPrefix(line, "Successfully tagged "))
+  }
+  return o.emit(buildEvent{Type: "output", Step: o.step, Message: line})
+}
+
+// finishStep reports the end of the current step, if any.
+func (o *jsonProgressOutput) finishStep() error {
+  if o.step == 0 {
+    return nil
+  }
+  status := "done"
+  if o.cached {
+    status = "cached"
+  }
+  event := buildEvent{
+    Type:        "step",
+    Step:        o.step,
+    TotalSteps:  o.totalSteps,
+    Instruction: o.instruction,
+    Status:      status,
+    DurationMs:  durationMs(o.now().Sub(o.stepStart)),
+  }
+  o.step = 0
+  return o.emit(event)
+}
+
+// Finish reports the result of the build. engineID is the ID reported by
+// the server, if any, and buildErr the error the build failed with.
+func (o *jsonProgressOutput) Finish(engineID string, buildErr error) error {
+  failed := buildErr != nil
+  if !failed {
+    if err := o.finishStep(); err != nil {
+      return err
+    }
+  }
+  if engineID != "" {
+    o.engineID = engineID
+  }
+
+  event := buildEvent{
+    Type:       "result",
+    Status:     "success",
+    DurationMs: durationMs(o.now().Sub(o.start)),
+    Summary: &buildSummary{
+      EngineID:  o.engineID,
+      Tags:      o.tags,
+      Steps:     o.steps,
+      CacheHits: o.cacheHits,
+    },
+  }
+  if failed {
+    event.Status = "failed"
+    event.Error = buildErr.Error()
+  }
+  return o.emit(event)
+}
+
+func durationMs(d time.Duration) int64 {
+  return int64(d / time.Millisecond)
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+  "fmt"
+  "io"
+  "path/filepath"
+
+  "golang.org/x/net/context"
+
+  "github.com/Sirupsen/logrus"
+  "github.com/TopPano/providence-cli/api/errdefs"
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/builder"
+  "github.com/TopPano/providence-cli/cli/command"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/docker/docker/pkg/archive"
+  units "github.com/docker/go-units"
+)
+
+// hashCacheFilename is the file of the config directory remembering the
+// hashes of the files of the build contexts.
+const hashCacheFilename = "context-hashes.json"
+
+// hashContext hashes the files of the context directory sent to the server.
+// The hashes are cached when useCache is set, which is pointless for
+// temporary context directories such as git clones.
+func hashContext(contextDir string, includes, excludes []string, useCache bool) ([]*builder.HashedFileInfo, error) {
+  var cache *builder.HashCache
+  if useCache {
+    var err error
+    if cache, err = builder.LoadHashCache(filepath.Join(cliconfig.Dir(), hashCacheFilename)); err != nil {
+      logrus.Debugf("Ignoring the context hash cache: %v", err)
+    }
+  }
+
+  files, err := builder.HashContext(contextDir, includes, excludes, cache)
+  if err != nil {
+    return nil, err
+  }
+  if cache != nil {
+    if err := cache.Save(); err != nil {
+      logrus.Debugf("Failed to save the context hash cache: %v", err)
+    }
+  }
+  return files, nil
+}
+
+// syncContext opens a context session for the hashed files of contextDir
+// and returns the build context to upload for it with the ID of the
+// session. It returns a nil context when the server doesn't support
+// context sessions, the full build context must be sent then.
+func syncContext(ctx context.Context, provCli *command.ProvCli, out io.Writer, contextDir string, files []*builder.HashedFileInfo, compression archive.Compression) (io.ReadCloser, string, error) {
+  session, err := provCli.Client().ContextSession(ctx, types.ContextSessionRequest{Hashes: builder.ContextHashes(files)})
+  if err != nil {
+    if errdefs.IsNotFound(err) || errdefs.IsNotImplemented(err) {
+      fmt.Fprintln(out, "The server doesn't support incremental context sync, sending the full build context")
+      return nil, "", nil
+    }
+    return nil, "", err
+  }
+
+  missing := map[string]bool{}
+  for _, hash := range session.Missing {
+    missing[hash] = true
+  }
+  var (
+    total, uploads int
+    size           int64
+  )
+  for _, fi := range files {
+    if fi.Hash() == "" {
+      continue
+    }
+    total++
+    if missing[fi.Hash()] {
+      delete(missing, fi.Hash())
+      uploads++
+      size += fi.Size()
+    }
+  }
+  fmt.Fprintf(out, "Syncing build context: %d of %d files to upload (%s)\n", uploads, total, units.HumanSize(float64(size)))
+
+  buildCtx, err := builder.SyncContext(contextDir, files, session.Missing, compression)
+  if err != nil {
+    return nil, "", err
+  }
+  return buildCtx, session.ID, nil
+}
@@ -0,0 +1,659 @@
+package engine
+
+import (
+  "archive/tar"
+  "compress/gzip"
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "reflect"
+  "sort"
+  "strings"
+  "testing"
+  "time"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/TopPano/providence-cli/client"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/fakeserver"
+)
+
+func TestMain(m *testing.M) {
+  // Keep the context hash cache of the builds out of the config directory
+  // of the user
+  configDir, err := ioutil.TempDir("", "prov-build-config-")
+  if err != nil {
+    panic(err)
+  }
+  cliconfig.SetDir(configDir)
+  code := m.Run()
+  os.RemoveAll(configDir)
+  os.Exit(code)
+}
+
+func newBuildContext(t *testing.T) string {
+  dir, err := ioutil.TempDir("", "prov-build-test-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := ioutil.WriteFile(filepath.Join(dir, "Enginefile"), []byte("FROM scratch\nARG model\n"), 0644); err != nil {
+    os.RemoveAll(dir)
+    t.Fatal(err)
+  }
+  return dir
+}
+
+func newFakeServerCli(t *testing.T, server *fakeserver.Server) *test.FakeCli {
+  apiClient, err := client.NewClientWithOpts(client.WithHost(server.Host()))
+  if err != nil {
+    t.Fatal(err)
+  }
+  return test.NewFakeCli(apiClient)
+}
+
+func TestBuildAgainstFakeServer(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.BuildSteps = []string{"FROM scratch", "ARG model"}
+
+  provCli := newFakeServerCli(t, server)
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "-t", "vision/detector", "--build-arg", "model=resnet", "--build-arg", "batch=8", contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  out := provCli.OutBuffer().String()
+  for _, expected := range []string{"Step 1/2 : FROM scratch", "Step 2/2 : ARG model", "Successfully tagged vision/detector:latest"} {
+    if !strings.Contains(out, expected) {
+      t.Fatalf("expected the output to contain %q, got %s", expected, out)
+    }
+  }
+  if warning := "[Warning] One or more build-args [batch] were not consumed"; !strings.Contains(provCli.ErrBuffer().String(), warning) {
+    t.Fatalf("expected a warning about unused build args, got %s", provCli.ErrBuffer().String())
+  }
+}
+
+func TestBuildErrorAgainstFakeServer(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.BuildError = "unknown instruction: FORM"
+
+  provCli := newFakeServerCli(t, server)
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), contextDir)
+  if sterr, ok := err.(cli.StatusError); !ok || sterr.Status != server.BuildError || sterr.StatusCode != 1 {
+    t.Fatalf("expected the build error to be reported, got %v", err)
+  }
+}
+
+func TestBuildJSONProgressAgainstFakeServer(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.BuildSteps = []string{"FROM scratch", "ARG model"}
+  server.CachedSteps = 1
+
+  provCli := newFakeServerCli(t, server)
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--progress", "json", "-t", "vision/detector", contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  var events []buildEvent
+  for _, line := range strings.Split(strings.TrimSpace(provCli.OutBuffer().String()), "\n") {
+    var event buildEvent
+    if err := json.Unmarshal([]byte(line), &event); err != nil {
+      t.Fatalf("expected one JSON event per line, got %q: %v", line, err)
+    }
+    events = append(events, event)
+  }
+
+  if events[0].Type != "context" || events[0].Progress == nil {
+    t.Fatalf("expected the first event to report the context upload, got %+v", events[0])
+  }
+  var steps []string
+  for _, e := range events {
+    if e.Type == "step" {
+      steps = append(steps, fmt.Sprintf("%d/%d %s %s", e.Step, e.TotalSteps, e.Instruction, e.Status))
+    }
+  }
+  expectedSteps := []string{"1/2 FROM scratch started", "1/2 FROM scratch cached", "2/2 ARG model started", "2/2 ARG model done"}
+  if !reflect.DeepEqual(steps, expectedSteps) {
+    t.Fatalf("expected steps %v, got %v", expectedSteps, steps)
+  }
+
+  result := events[len(events)-1]
+  if result.Type != "result" || result.Status != "success" || result.Summary == nil {
+    t.Fatalf("expected the last event to be a successful result, got %+v", result)
+  }
+  engines := server.Engines()
+  expected := buildSummary{EngineID: engines[0].ID, Tags: []string{"vision/detector:latest"}, Steps: 2, CacheHits: 1}
+  if !reflect.DeepEqual(*result.Summary, expected) {
+    t.Fatalf("expected summary %+v, got %+v", expected, *result.Summary)
+  }
+}
+
+func TestBuildJSONProgressError(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.BuildError = "unknown instruction: FORM"
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--progress", "json", contextDir); err == nil {
+    t.Fatal("expected the build to fail")
+  }
+
+  lines := strings.Split(strings.TrimSpace(provCli.OutBuffer().String()), "\n")
+  var result buildEvent
+  if err := json.Unmarshal([]byte(lines[len(lines)-1]), &result); err != nil {
+    t.Fatal(err)
+  }
+  if result.Type != "result" || result.Status != "failed" || result.Error != server.BuildError {
+    t.Fatalf("expected a failed result, got %+v", result)
+  }
+}
+
+func TestBuildPlainProgressCollapsesUpload(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--progress", "plain", contextDir); err != nil {
+    t.Fatal(err)
+  }
+  out := provCli.OutBuffer().String()
+  if n := strings.Count(out, "Sending build context"); n != 1 {
+    t.Fatalf("expected the upload progress to be reported once, got %d times in %q", n, out)
+  }
+}
+
+func TestBuildInvalidProgressMode(t *testing.T) {
+  provCli := test.NewFakeCli(&test.FakeClient{})
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--progress", "fancy", ".")
+  if err == nil || !strings.Contains(err.Error(), `invalid progress mode "fancy"`) {
+    t.Fatalf("expected an invalid progress mode error, got %v", err)
+  }
+}
+
+func TestBuildQuietWithIDAndMetadataFiles(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  outDir, err := ioutil.TempDir("", "prov-build-out-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(outDir)
+  iidFile := filepath.Join(outDir, "iid")
+  metadataFile := filepath.Join(outDir, "metadata.json")
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.BuildSteps = []string{"FROM scratch", "ARG model"}
+
+  provCli := newFakeServerCli(t, server)
+  err = provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "-q", "-t", "vision/detector", "--build-arg", "model=resnet",
+    "--iidfile", iidFile, "--metadata-file", metadataFile, contextDir)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  engineID := server.Engines()[0].ID
+  if out := provCli.OutBuffer().String(); out != engineID+"\n" {
+    t.Fatalf("expected only the engine ID on stdout, got %q", out)
+  }
+
+  iid, err := ioutil.ReadFile(iidFile)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if string(iid) != engineID {
+    t.Fatalf("expected the ID file to contain %s, got %q", engineID, iid)
+  }
+
+  b, err := ioutil.ReadFile(metadataFile)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var metadata buildMetadata
+  if err := json.Unmarshal(b, &metadata); err != nil {
+    t.Fatal(err)
+  }
+  if metadata.EngineID != engineID || metadata.Enginefile != "Enginefile" || !strings.HasPrefix(metadata.ContextDigest, "sha256:") {
+    t.Fatalf("unexpected metadata %s", b)
+  }
+  if !reflect.DeepEqual(metadata.Tags, []string{"vision/detector:latest"}) {
+    t.Fatalf("expected the normalized tags, got %v", metadata.Tags)
+  }
+  if model := metadata.BuildArgs["model"]; model == nil || *model != "resnet" {
+    t.Fatalf("expected the build args, got %v", metadata.BuildArgs)
+  }
+}
+
+func TestBuildQuietKeepsCompression(t *testing.T) {
+  cmd := NewBuildCommand(test.NewFakeCli(&test.FakeClient{}).ProvCli)
+  if err := cmd.ParseFlags([]string{"-q"}); err != nil {
+    t.Fatal(err)
+  }
+  if compress, _ := cmd.Flags().GetBool("compress"); !compress {
+    t.Fatal("expected --quiet to leave compression enabled")
+  }
+}
+
+// contextFiles returns the names of the regular files of a gzipped tar
+// build context.
+func contextFiles(t *testing.T, buildContext io.Reader) []string {
+  gz, err := gzip.NewReader(buildContext)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var names []string
+  tr := tar.NewReader(gz)
+  for {
+    hdr, err := tr.Next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      t.Fatal(err)
+    }
+    if hdr.Typeflag == tar.TypeReg {
+      names = append(names, hdr.Name)
+    }
+  }
+  sort.Strings(names)
+  return names
+}
+
+func TestBuildMinimalContext(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  for name, content := range map[string]string{
+    "Enginefile":         "FROM scratch\nCOPY model.json weights/*.bin /srv/\n",
+    "model.json":         "{}",
+    "weights/a.bin":      "a",
+    "weights/b.txt":      "b",
+    "datasets/train.csv": strings.Repeat("x", 4096),
+  } {
+    os.MkdirAll(filepath.Join(contextDir, filepath.Dir(name)), 0755)
+    if err := ioutil.WriteFile(filepath.Join(contextDir, name), []byte(content), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  var files []string
+  provCli := test.NewFakeCli(&test.FakeClient{
+    EngineBuildFunc: func(ctx context.Context, buildContext io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error) {
+      files = contextFiles(t, buildContext)
+      return types.EngineBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    },
+  })
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--minimal-context", contextDir); err != nil {
+    t.Fatal(err)
+  }
+
+  expected := []string{"Enginefile", "model.json", "weights/a.bin"}
+  if !reflect.DeepEqual(files, expected) {
+    t.Fatalf("expected the context to contain %v, got %v", expected, files)
+  }
+  if out := provCli.OutBuffer().String(); !strings.Contains(out, "Minimal build context: 3 of 5 files") {
+    t.Fatalf("expected the context reduction to be reported, got %q", out)
+  }
+}
+
+func TestBuildMinimalContextFallback(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  if err := ioutil.WriteFile(filepath.Join(contextDir, "Enginefile"), []byte("FROM scratch\nARG model\nCOPY $model /srv/\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+
+  var files []string
+  provCli := test.NewFakeCli(&test.FakeClient{
+    EngineBuildFunc: func(ctx context.Context, buildContext io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error) {
+      files = contextFiles(t, buildContext)
+      return types.EngineBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    },
+  })
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--minimal-context", contextDir); err != nil {
+    t.Fatal(err)
+  }
+  if !reflect.DeepEqual(files, []string{"Enginefile"}) {
+    t.Fatalf("expected the full context, got %v", files)
+  }
+  if out := provCli.OutBuffer().String(); !strings.Contains(out, `Sending the full build context: line 3: COPY source "$model" references a variable`) {
+    t.Fatalf("expected the fallback to be reported, got %q", out)
+  }
+}
+
+func TestBuildSyncAgainstFakeServer(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  if err := ioutil.WriteFile(filepath.Join(contextDir, "model.json"), []byte("{}"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  configDir, err := ioutil.TempDir("", "prov-config-test-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(configDir)
+  defer cliconfig.SetDir(cliconfig.Dir())
+  cliconfig.SetDir(configDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  for _, expected := range []string{"2 of 2 files to upload", "0 of 2 files to upload"} {
+    provCli := newFakeServerCli(t, server)
+    if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--sync", contextDir); err != nil {
+      t.Fatal(err)
+    }
+    if out := provCli.OutBuffer().String(); !strings.Contains(out, "Syncing build context: "+expected) {
+      t.Fatalf("expected %q to be reported, got %q", expected, out)
+    }
+  }
+  if _, err := os.Stat(filepath.Join(configDir, hashCacheFilename)); err != nil {
+    t.Fatalf("expected the hashes to be cached: %v", err)
+  }
+  if engines := server.Engines(); len(engines) != 1 {
+    t.Fatalf("expected both builds to give the same engine, got %d engines", len(engines))
+  }
+}
+
+func TestBuildSyncFallback(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  configDir, err := ioutil.TempDir("", "prov-config-test-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(configDir)
+  defer cliconfig.SetDir(cliconfig.Dir())
+  cliconfig.SetDir(configDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+  server.InjectError("POST", "/context/session", fakeserver.InjectedError{StatusCode: 404, Message: "page not found"})
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--sync", contextDir); err != nil {
+    t.Fatal(err)
+  }
+  out := provCli.OutBuffer().String()
+  for _, expected := range []string{"The server doesn't support incremental context sync", "Successfully built"} {
+    if !strings.Contains(out, expected) {
+      t.Fatalf("expected the output to contain %q, got %q", expected, out)
+    }
+  }
+}
+
+func TestBuildReproducible(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  outDir, err := ioutil.TempDir("", "prov-build-out-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(outDir)
+  metadataFile := filepath.Join(outDir, "metadata.json")
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  var digests []string
+  for _, mtime := range []time.Time{time.Unix(1500000000, 0), time.Unix(1600000000, 0)} {
+    if err := os.Chtimes(filepath.Join(contextDir, "Enginefile"), mtime, mtime); err != nil {
+      t.Fatal(err)
+    }
+    provCli := newFakeServerCli(t, server)
+    if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--reproducible", "--metadata-file", metadataFile, contextDir); err != nil {
+      t.Fatal(err)
+    }
+
+    b, err := ioutil.ReadFile(metadataFile)
+    if err != nil {
+      t.Fatal(err)
+    }
+    var metadata buildMetadata
+    if err := json.Unmarshal(b, &metadata); err != nil {
+      t.Fatal(err)
+    }
+    if out := provCli.OutBuffer().String(); !strings.Contains(out, "Build context digest: "+metadata.ContextDigest+"\n") {
+      t.Fatalf("expected the digest %s to be printed, got %q", metadata.ContextDigest, out)
+    }
+    digests = append(digests, metadata.ContextDigest)
+  }
+  if digests[0] != digests[1] {
+    t.Fatalf("expected the same context digest, got %v", digests)
+  }
+  if engines := server.Engines(); len(engines) != 1 {
+    t.Fatalf("expected both builds to upload the same context, got %d engines", len(engines))
+  }
+}
+
+func TestBuildReproducibleRequiresLocalContext(t *testing.T) {
+  provCli := test.NewFakeCli(&test.FakeClient{}, test.WithStdin(strings.NewReader("FROM scratch\n")))
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--reproducible", "-")
+  if err == nil || !strings.Contains(err.Error(), "--reproducible requires a local context directory") {
+    t.Fatalf("expected a context from stdin to be rejected, got %v", err)
+  }
+
+  // Reproducible build contexts are the default for local contexts only
+  provCli = test.NewFakeCli(&test.FakeClient{}, test.WithStdin(strings.NewReader("FROM scratch\n")))
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "-"); err != nil {
+    t.Fatal(err)
+  }
+}
+
+func TestBuildLocalContextFlagsRequireLocalContext(t *testing.T) {
+  for _, flag := range []string{"--sync", "--minimal-context"} {
+    provCli := test.NewFakeCli(&test.FakeClient{}, test.WithStdin(strings.NewReader("FROM scratch\n")))
+    err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), flag, "-")
+    if err == nil || err.Error() != flag+" requires a local context directory or a git repository" {
+      t.Fatalf("expected %s to be rejected for a context from stdin, got %v", flag, err)
+    }
+  }
+}
+
+func TestBuildReproducibleUsesCachedContext(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), contextDir); err != nil {
+    t.Fatal(err)
+  }
+  if out := provCli.OutBuffer().String(); strings.Contains(out, "Using cached build context") {
+    t.Fatalf("expected the first build to upload the context, got %q", out)
+  }
+
+  provCli = newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), contextDir); err != nil {
+    t.Fatal(err)
+  }
+  out := provCli.OutBuffer().String()
+  if !strings.Contains(out, "Using cached build context sha256:") || strings.Contains(out, "Sending build context") {
+    t.Fatalf("expected the cached context to be used, got %q", out)
+  }
+  if engines := server.Engines(); len(engines) != 1 {
+    t.Fatalf("expected both builds to give the same engine, got %d engines", len(engines))
+  }
+}
+
+func TestBuildReproducibleUploadsDroppedContext(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), contextDir); err != nil {
+    t.Fatal(err)
+  }
+
+  // The server drops the build context after it was looked up
+  server.InjectError("POST", "/engine", fakeserver.InjectedError{StatusCode: 404, Message: "build context not found", Times: 1})
+  provCli = newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), contextDir); err != nil {
+    t.Fatal(err)
+  }
+  out := provCli.OutBuffer().String()
+  for _, expected := range []string{"Using cached build context", "The server no longer holds build context", "Successfully built"} {
+    if !strings.Contains(out, expected) {
+      t.Fatalf("expected the output to contain %q, got %q", expected, out)
+    }
+  }
+}
+
+func TestBuildReproducibleUploadsWhenCacheLookupFails(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+
+  var options types.EngineBuildOptions
+  uploaded := false
+  provCli := test.NewFakeCli(&test.FakeClient{
+    ContextCachedFunc: func(ctx context.Context, digest string) (bool, error) {
+      return false, fmt.Errorf("method not allowed")
+    },
+    EngineBuildFunc: func(ctx context.Context, buildContext io.Reader, buildOptions types.EngineBuildOptions) (types.EngineBuildResponse, error) {
+      options = buildOptions
+      uploaded = buildContext != nil && len(contextFiles(t, buildContext)) > 0
+      return types.EngineBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    },
+  })
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--reproducible", contextDir); err != nil {
+    t.Fatal(err)
+  }
+  if !uploaded || options.ContextDigest != "" {
+    t.Fatalf("expected the build context to be uploaded, got %+v", options)
+  }
+}
+
+func TestBuildGitContextSubdirIsRemoved(t *testing.T) {
+  if _, err := exec.LookPath("git"); err != nil {
+    t.Skip("git is required")
+  }
+  tmpDir, err := ioutil.TempDir("", "prov-build-git-test-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(tmpDir)
+  repo := filepath.Join(tmpDir, "repo")
+  if err := os.MkdirAll(filepath.Join(repo, "engine"), 0755); err != nil {
+    t.Fatal(err)
+  }
+  if err := ioutil.WriteFile(filepath.Join(repo, "engine", "Enginefile"), []byte("FROM scratch\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", "init"}} {
+    args = append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+    if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+      t.Fatalf("git %v: %v (%s)", args, err, output)
+    }
+  }
+
+  oldTmpDir := os.Getenv("TMPDIR")
+  os.Setenv("TMPDIR", tmpDir)
+  defer os.Setenv("TMPDIR", oldTmpDir)
+
+  var files []string
+  provCli := test.NewFakeCli(&test.FakeClient{
+    EngineBuildFunc: func(ctx context.Context, buildContext io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error) {
+      files = contextFiles(t, buildContext)
+      return types.EngineBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    },
+  })
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "file://"+repo+"#:engine"); err != nil {
+    t.Fatal(err)
+  }
+  if !reflect.DeepEqual(files, []string{"Enginefile"}) {
+    t.Fatalf("expected the context of the engine directory, got %v", files)
+  }
+  if clones, _ := filepath.Glob(filepath.Join(tmpDir, "providence-build-git*")); len(clones) != 0 {
+    t.Fatalf("expected the clone to be removed, found %v", clones)
+  }
+}
+
+func TestBuildMetadataContextDigestIsStable(t *testing.T) {
+  contextDir := newBuildContext(t)
+  defer os.RemoveAll(contextDir)
+  outDir, err := ioutil.TempDir("", "prov-build-out-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(outDir)
+  defer cliconfig.SetDir(cliconfig.Dir())
+  cliconfig.SetDir(outDir)
+  metadataFile := filepath.Join(outDir, "metadata.json")
+
+  server := fakeserver.New()
+  defer server.Close()
+
+  readDigest := func() string {
+    b, err := ioutil.ReadFile(metadataFile)
+    if err != nil {
+      t.Fatal(err)
+    }
+    var metadata map[string]interface{}
+    if err := json.Unmarshal(b, &metadata); err != nil {
+      t.Fatal(err)
+    }
+    digest, _ := metadata["contextDigest"].(string)
+    return digest
+  }
+
+  digests := map[string]string{}
+  for _, mode := range []string{"", "--compress=false", "--sync", "--reproducible"} {
+    args := []string{"--metadata-file", metadataFile, contextDir}
+    if mode != "" {
+      args = append([]string{mode}, args...)
+    }
+    provCli := newFakeServerCli(t, server)
+    if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), args...); err != nil {
+      t.Fatal(err)
+    }
+    digests[mode] = readDigest()
+  }
+  for _, digest := range digests {
+    if !strings.HasPrefix(digest, "sha256:") || digest != digests[""] {
+      t.Fatalf("expected the same context digest for every mode, got %v", digests)
+    }
+  }
+
+  // The files of a context read from stdin aren't known
+  apiClient, err := client.NewClientWithOpts(client.WithHost(server.Host()))
+  if err != nil {
+    t.Fatal(err)
+  }
+  provCli := test.NewFakeCli(apiClient, test.WithStdin(strings.NewReader("FROM scratch\n")))
+  if err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--metadata-file", metadataFile, "-"); err != nil {
+    t.Fatal(err)
+  }
+  if digest := readDigest(); digest != "" {
+    t.Fatalf("expected no context digest for a context read from stdin, got %s", digest)
+  }
+}
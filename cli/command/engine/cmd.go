@@ -21,6 +21,11 @@ func NewEngineCommand(provCli *command.ProvCli) *cobra.Command {
   }
   cmd.AddCommand(
     NewBuildCommand(provCli),
+    NewInspectCommand(provCli),
+    NewLintCommand(provCli),
+    NewListCommand(provCli),
+    NewRemoveCommand(provCli),
+    NewTagCommand(provCli),
   )
 
   return cmd
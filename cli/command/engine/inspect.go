@@ -0,0 +1,48 @@
+package engine
+
+import (
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/TopPano/providence-cli/cli/command/formatter"
+  "github.com/TopPano/providence-cli/cli/command/inspect"
+  "github.com/dnephin/cobra"
+)
+
+type inspectOptions struct {
+  format string
+  refs   []string
+}
+
+// NewInspectCommand creates a new `prov engine inspect` command
+func NewInspectCommand(provCli *command.ProvCli) *cobra.Command {
+  var opts inspectOptions
+
+  cmd := &cobra.Command{
+    Use:    "inspect [OPTIONS] ENGINE [ENGINE...]",
+    Short:  "Display detailed information on one or more engines",
+    Args:   cli.RequiresMinArgs(1),
+    Tags:   map[string]string{"version": "1.1"},
+    RunE:   func(cmd *cobra.Command, args []string) error {
+      opts.refs = args
+      return runInspect(provCli, opts)
+    },
+  }
+
+  flags := cmd.Flags()
+  flags.StringVarP(&opts.format, "format", "f", "", "Format the output using 'json' or the given Go template")
+
+  return cmd
+}
+
+func runInspect(provCli *command.ProvCli, opts inspectOptions) error {
+  client := provCli.Client()
+  ctx := context.Background()
+
+  getRefFunc := func(ref string) (interface{}, []byte, error) {
+    return client.EngineInspectWithRaw(ctx, ref)
+  }
+  format := formatter.Resolve(opts.format, provCli.ConfigFile().EngineInspectFormat, "")
+  return inspect.Inspect(provCli.Out(), opts.refs, format, getRefFunc)
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/golden"
+)
+
+func TestInspectFormats(t *testing.T) {
+  cases := []struct {
+    args       []string
+    configFile *cliconfig.ConfigFile
+    golden     string
+  }{
+    {args: []string{"vision/detector"}, golden: "engine-inspect-json.golden"},
+    {args: []string{"--format", "json", "vision/detector"}, golden: "engine-inspect-json.golden"},
+    {args: []string{"--format", "{{.ID}} {{upper .Enginefile}}", "vision/detector"}, golden: "engine-inspect-format.golden"},
+    {args: []string{"vision/detector"}, configFile: &cliconfig.ConfigFile{EngineInspectFormat: "{{.ID}} {{upper .Enginefile}}"}, golden: "engine-inspect-format.golden"},
+    {args: []string{"--format", "json", "vision/detector"}, configFile: &cliconfig.ConfigFile{EngineInspectFormat: "{{.ID}}"}, golden: "engine-inspect-json.golden"},
+  }
+
+  for _, c := range cases {
+    client := &test.FakeClient{
+      EngineInspectWithRawFunc: func(ctx context.Context, engineID string) (types.EngineInspect, []byte, error) {
+        return types.EngineInspect{ID: "sha256:4f53cda18c2b", Enginefile: "Enginefile"}, nil, nil
+      },
+    }
+    opts := []test.FakeCliOption{}
+    if c.configFile != nil {
+      opts = append(opts, test.WithConfigFile(c.configFile))
+    }
+    cli := test.NewFakeCli(client, opts...)
+    if err := cli.RunCommand(NewInspectCommand(cli.ProvCli), c.args...); err != nil {
+      t.Fatal(err)
+    }
+    golden.Assert(t, cli.OutBuffer().String(), c.golden)
+  }
+}
+
+func TestInspectInvalidFormat(t *testing.T) {
+  provCli := test.NewFakeCli(&test.FakeClient{})
+  err := provCli.RunCommand(NewInspectCommand(provCli.ProvCli), "--format", "{{.ID", "vision/detector")
+  if sterr, ok := err.(cli.StatusError); !ok || sterr.StatusCode != cli.ExitCodeInvalidParameter {
+    t.Fatalf("expected an invalid parameter error, got %#v", err)
+  }
+}
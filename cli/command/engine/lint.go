@@ -0,0 +1,116 @@
+package engine
+
+import (
+  "encoding/json"
+  "fmt"
+  "os"
+  "path/filepath"
+
+  "github.com/TopPano/providence-cli/builder"
+  "github.com/TopPano/providence-cli/builder/enginefile"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/TopPano/providence-cli/cli/command/formatter"
+  "github.com/TopPano/providence-cli/opts"
+  "github.com/dnephin/cobra"
+)
+
+type lintOptions struct {
+  context        string
+  enginefileName string
+  buildArgs      opts.ListOpts
+  format         string
+}
+
+// NewLintCommand creates a new `prov engine lint` command
+func NewLintCommand(provCli *command.ProvCli) *cobra.Command {
+  options := lintOptions{buildArgs: opts.NewListOpts(opts.ValidateEnv)}
+
+  cmd := &cobra.Command{
+    Use:   "lint [OPTIONS] [PATH]",
+    Short: "Check an Enginefile for errors without building it",
+    Args:  cli.RequiresMaxArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+      options.context = "."
+      if len(args) > 0 {
+        options.context = args[0]
+      }
+      return runLint(provCli, options)
+    },
+  }
+
+  flags := cmd.Flags()
+  flags.StringVarP(&options.enginefileName, "file", "f", "", "Name of the Enginefile (Default is 'PATH/Enginefile')")
+  flags.Var(&options.buildArgs, "build-arg", "Set build-time variables")
+  flags.StringVar(&options.format, "format", "", "Print the findings in the given format (text or json)")
+
+  return cmd
+}
+
+func runLint(provCli *command.ProvCli, options lintOptions) error {
+  contextDir, relEnginefile, err := builder.GetContextFromLocalDir(options.context, options.enginefileName)
+  if err != nil {
+    return fmt.Errorf("unable to prepare context: %s", err)
+  }
+  return lintEnginefile(provCli, contextDir, relEnginefile, options)
+}
+
+// lintEnginefile checks the Enginefile of the context directory and prints
+// the findings. It returns a StatusError if a problem was found.
+func lintEnginefile(provCli *command.ProvCli, contextDir, relEnginefile string, options lintOptions) error {
+  if options.format != "" && options.format != "text" && options.format != formatter.JSONFormatKey {
+    return cli.StatusError{StatusCode: cli.ExitCodeInvalidParameter,
+      Status: fmt.Sprintf("invalid format %q: must be text or json", options.format)}
+  }
+
+  excludes, err := readProvignore(contextDir)
+  if err != nil {
+    return err
+  }
+
+  filename := options.enginefileName
+  if filename == "" {
+    filename = filepath.Join(options.context, relEnginefile)
+  }
+  f, err := os.Open(filepath.Join(contextDir, relEnginefile))
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  findings, err := enginefile.Lint(f, enginefile.LintOptions{
+    Filename:   filename,
+    ContextDir: contextDir,
+    Excludes:   excludes,
+    BuildArgs:  opts.ConvertKVStringsToMapWithNil(options.buildArgs.GetAll()),
+  })
+  if err != nil {
+    return err
+  }
+
+  if options.format == formatter.JSONFormatKey {
+    if findings == nil {
+      findings = []enginefile.Finding{}
+    }
+    if err := json.NewEncoder(provCli.Out()).Encode(findings); err != nil {
+      return err
+    }
+  } else {
+    for _, finding := range findings {
+      fmt.Fprintln(provCli.Out(), finding)
+    }
+    if len(findings) == 0 {
+      fmt.Fprintf(provCli.Out(), "%s: no problems found\n", filename)
+    }
+  }
+
+  // The summary goes to stderr, so it doesn't break the JSON output
+  switch len(findings) {
+  case 0:
+    return nil
+  case 1:
+    return cli.StatusError{StatusCode: 1, Status: "1 problem found"}
+  default:
+    return cli.StatusError{StatusCode: 1, Status: fmt.Sprintf("%d problems found", len(findings))}
+  }
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/golden"
+)
+
+func newLintContext(t *testing.T, enginefile string) string {
+  dir, err := ioutil.TempDir("", "prov-lint-test-")
+  if err != nil {
+    t.Fatal(err)
+  }
+  files := map[string]string{
+    "Enginefile":  enginefile,
+    "model.json":  "{}",
+    ".provignore": "secrets\n",
+  }
+  for name, content := range files {
+    if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+      os.RemoveAll(dir)
+      t.Fatal(err)
+    }
+  }
+  if err := os.Mkdir(filepath.Join(dir, "secrets"), 0755); err != nil {
+    os.RemoveAll(dir)
+    t.Fatal(err)
+  }
+  return dir
+}
+
+func TestLintFormats(t *testing.T) {
+  contextDir := newLintContext(t, "FROM scratch\nARG model\nCOPY model.json secrets /srv/\nFORM scratch\n")
+  defer os.RemoveAll(contextDir)
+
+  cases := []struct {
+    args   []string
+    golden string
+  }{
+    {args: []string{"-f", "Enginefile", "--build-arg", "batch=8"}, golden: "engine-lint.golden"},
+    {args: []string{"-f", "Enginefile", "--format", "json", "--build-arg", "batch=8"}, golden: "engine-lint-json.golden"},
+  }
+  for _, c := range cases {
+    provCli := test.NewFakeCli(&test.FakeClient{})
+    // -f is relative to the current directory, which keeps the reported
+    // file name stable
+    cwd, _ := os.Getwd()
+    os.Chdir(contextDir)
+    err := provCli.RunCommand(NewLintCommand(provCli.ProvCli), append(c.args, ".")...)
+    os.Chdir(cwd)
+    if sterr, ok := err.(cli.StatusError); !ok || sterr.StatusCode != 1 || !strings.HasSuffix(sterr.Status, " problems found") {
+      t.Fatalf("expected the lint to fail with exit code 1 and a summary, got %#v", err)
+    }
+    golden.Assert(t, provCli.OutBuffer().String(), c.golden)
+  }
+}
+
+func TestLintNoProblems(t *testing.T) {
+  contextDir := newLintContext(t, "FROM scratch\nCOPY model.json /srv/\n")
+  defer os.RemoveAll(contextDir)
+
+  provCli := test.NewFakeCli(&test.FakeClient{})
+  if err := provCli.RunCommand(NewLintCommand(provCli.ProvCli), "--format", "json", contextDir); err != nil {
+    t.Fatal(err)
+  }
+  if out := provCli.OutBuffer().String(); out != "[]\n" {
+    t.Fatalf("expected no findings, got %q", out)
+  }
+}
+
+func TestBuildCheck(t *testing.T) {
+  contextDir := newLintContext(t, "FROM scratch\nFORM scratch\n")
+  defer os.RemoveAll(contextDir)
+
+  provCli := test.NewFakeCli(&test.FakeClient{})
+  err := provCli.RunCommand(NewBuildCommand(provCli.ProvCli), "--check", contextDir)
+  if sterr, ok := err.(cli.StatusError); !ok || sterr.Status != "1 problem found" {
+    t.Fatalf("expected the check to fail, got %v", err)
+  }
+}
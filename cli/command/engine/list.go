@@ -0,0 +1,84 @@
+package engine
+
+import (
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/TopPano/providence-cli/cli/command/formatter"
+  "github.com/TopPano/providence-cli/opts"
+  "github.com/dnephin/cobra"
+)
+
+// acceptedEngineFilterTags lists the filter keys understood by `prov engine ls`.
+var acceptedEngineFilterTags = map[string]bool{
+  "label":    true,
+  "name":     true,
+  "before":   true,
+  "since":    true,
+  "dangling": true,
+}
+
+type listOptions struct {
+  quiet   bool
+  noTrunc bool
+  format  string
+  filter  opts.FilterOpt
+}
+
+// NewListCommand creates a new `prov engine ls` command
+func NewListCommand(provCli *command.ProvCli) *cobra.Command {
+  options := listOptions{filter: opts.NewFilterOpt()}
+
+  cmd := &cobra.Command{
+    Use:     "ls [OPTIONS]",
+    Aliases: []string{"list"},
+    Short:   "List engines",
+    Args:    cli.NoArgs,
+    Tags:    map[string]string{"version": "1.1"},
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return runList(provCli, options)
+    },
+  }
+
+  flags := cmd.Flags()
+
+  flags.BoolVarP(&options.quiet, "quiet", "q", false, "Only show engine IDs")
+  flags.BoolVar(&options.noTrunc, "no-trunc", false, "Don't truncate output")
+  flags.StringVar(&options.format, "format", "", formatter.FormatHelp)
+  flags.VarP(&options.filter, "filter", "f", "Filter output based on conditions provided")
+
+  return cmd
+}
+
+func runList(provCli *command.ProvCli, options listOptions) error {
+  ctx := context.Background()
+
+  filters := options.filter.Value()
+  if err := filters.Validate(acceptedEngineFilterTags); err != nil {
+    return err
+  }
+
+  engines, err := provCli.Client().EngineList(ctx, types.EngineListOptions{
+    Filters: filters,
+  })
+  if err != nil {
+    return err
+  }
+
+  // The default format of the config file doesn't apply to --quiet, which
+  // always prints one engine ID per line.
+  configFormat := provCli.ConfigFile().EnginesFormat
+  if options.quiet {
+    configFormat = ""
+  }
+  format := formatter.Resolve(options.format, configFormat, formatter.TableFormatKey)
+
+  engineCtx := formatter.Context{
+    Output: provCli.Out(),
+    Format: formatter.NewEngineFormat(format, options.quiet),
+    Trunc:  !options.noTrunc,
+  }
+  return formatter.EngineWrite(engineCtx, engines)
+}
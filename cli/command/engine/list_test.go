@@ -0,0 +1,82 @@
+package engine
+
+import (
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/golden"
+)
+
+var listEngines = []types.EngineSummary{
+  {ID: "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945", RepoTags: []string{"vision/detector:1.0", "vision/detector:latest"}, Size: 1024 * 1024},
+  {ID: "sha256:0b8a0b9ac6d8ea83bb8c24c1ccb5e1a6a4e3be7c3d5f1ff3b6d1a0e9c8f7a6b5", Size: 2048},
+}
+
+func TestListFormats(t *testing.T) {
+  cases := []struct {
+    args       []string
+    configFile *cliconfig.ConfigFile
+    golden     string
+  }{
+    {args: []string{"--format", "table {{.ID}}\t{{.Repository}}\t{{.Tag}}\t{{.Size}}"}, golden: "engine-list-table.golden"},
+    {args: []string{"--format", "table {{.ID}}\t{{.Repository}}", "--no-trunc"}, golden: "engine-list-no-trunc.golden"},
+    {args: []string{"-q"}, golden: "engine-list-quiet.golden"},
+    {configFile: &cliconfig.ConfigFile{EnginesFormat: "{{.Repository}}:{{.Tag}}"}, golden: "engine-list-config-format.golden"},
+    {args: []string{"--format", "{{.ID}}"}, configFile: &cliconfig.ConfigFile{EnginesFormat: "{{.Repository}}:{{.Tag}}"}, golden: "engine-list-flag-format.golden"},
+    {args: []string{"-q"}, configFile: &cliconfig.ConfigFile{EnginesFormat: "{{.Repository}}:{{.Tag}}"}, golden: "engine-list-quiet.golden"},
+    {args: []string{"--format", "json", "-q"}, golden: "engine-list-quiet.golden"},
+  }
+
+  for _, c := range cases {
+    client := &test.FakeClient{
+      EngineListFunc: func(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error) {
+        return listEngines, nil
+      },
+    }
+    opts := []test.FakeCliOption{}
+    if c.configFile != nil {
+      opts = append(opts, test.WithConfigFile(c.configFile))
+    }
+    cli := test.NewFakeCli(client, opts...)
+    if err := cli.RunCommand(NewListCommand(cli.ProvCli), c.args...); err != nil {
+      t.Fatal(err)
+    }
+    golden.Assert(t, cli.OutBuffer().String(), c.golden)
+  }
+}
+
+func TestListInvalidFilter(t *testing.T) {
+  cli := test.NewFakeCli(&test.FakeClient{})
+  err := cli.RunCommand(NewListCommand(cli.ProvCli), "--filter", "color=red")
+  if err == nil || err.Error() != "Invalid filter 'color'" {
+    t.Fatalf("expected an invalid filter error, got %v", err)
+  }
+}
+
+func TestListMalformedTag(t *testing.T) {
+  client := &test.FakeClient{
+    EngineListFunc: func(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error) {
+      return []types.EngineSummary{
+        {ID: "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945", RepoTags: []string{"Vision/Detector:1.0"}},
+        {ID: "sha256:0b8a0b9ac6d8ea83bb8c24c1ccb5e1a6a4e3be7c3d5f1ff3b6d1a0e9c8f7a6b5", RepoTags: []string{"vision/detector:latest"}},
+      }, nil
+    },
+  }
+  for _, c := range []struct {
+    args   []string
+    golden string
+  }{
+    {args: []string{"--format", "table {{.ID}}\t{{.Repository}}\t{{.Tag}}"}, golden: "engine-list-malformed-tag.golden"},
+    {args: []string{"-q"}, golden: "engine-list-quiet.golden"},
+  } {
+    cli := test.NewFakeCli(client)
+    if err := cli.RunCommand(NewListCommand(cli.ProvCli), c.args...); err != nil {
+      t.Fatal(err)
+    }
+    golden.Assert(t, cli.OutBuffer().String(), c.golden)
+  }
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+  "fmt"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/dnephin/cobra"
+)
+
+type removeOptions struct {
+  force   bool
+  noPrune bool
+}
+
+// NewRemoveCommand creates a new `prov engine rm` command
+func NewRemoveCommand(provCli *command.ProvCli) *cobra.Command {
+  var opts removeOptions
+
+  cmd := &cobra.Command{
+    Use:     "rm [OPTIONS] ENGINE [ENGINE...]",
+    Aliases: []string{"remove"},
+    Short:   "Remove one or more engines",
+    Args:    cli.RequiresMinArgs(1),
+    Tags:    map[string]string{"version": "1.1"},
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return runRemove(provCli, opts, args)
+    },
+  }
+
+  flags := cmd.Flags()
+
+  flags.BoolVarP(&opts.force, "force", "f", false, "Force removal of the engine")
+  flags.BoolVar(&opts.noPrune, "no-prune", false, "Do not delete untagged parents")
+
+  return cmd
+}
+
+func runRemove(provCli *command.ProvCli, opts removeOptions, engines []string) error {
+  client := provCli.Client()
+  ctx := context.Background()
+
+  options := types.EngineRemoveOptions{
+    Force:         opts.force,
+    PruneChildren: !opts.noPrune,
+  }
+
+  var errs cli.Errors
+  for _, engine := range engines {
+    dels, err := client.EngineRemove(ctx, engine, options)
+    if err != nil {
+      errs = append(errs, err)
+      continue
+    }
+
+    for _, del := range dels {
+      if del.Deleted != "" {
+        fmt.Fprintf(provCli.Out(), "Deleted: %s\n", del.Deleted)
+      } else {
+        fmt.Fprintf(provCli.Out(), "Untagged: %s\n", del.Untagged)
+      }
+    }
+  }
+
+  if len(errs) > 0 {
+    return cli.StatusError{Status: errs.Error(), StatusCode: cli.ExitCode(errs)}
+  }
+  return nil
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+  "strings"
+  "testing"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/client"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/fakeserver"
+  "github.com/TopPano/providence-cli/internal/test/golden"
+)
+
+func TestRemoveAgainstFakeServer(t *testing.T) {
+  server := fakeserver.New()
+  defer server.Close()
+  server.AddEngine(types.EngineInspect{ID: "sha256:4f53cda18c2baa0c", RepoTags: []string{"vision/detector:1.0", "vision/detector:latest"}})
+
+  apiClient, err := client.NewClientWithOpts(client.WithHost(server.Host()))
+  if err != nil {
+    t.Fatal(err)
+  }
+  provCli := test.NewFakeCli(apiClient)
+
+  err = provCli.RunCommand(NewRemoveCommand(provCli.ProvCli), "vision/detector:1.0", "4f53cda18c2b", "missing")
+  sterr, ok := err.(cli.StatusError)
+  if !ok || sterr.StatusCode != cli.ExitCodeNotFound || !strings.Contains(sterr.Status, "No such engine: missing") {
+    t.Fatalf("expected the missing engine to be reported, got %v", err)
+  }
+  golden.Assert(t, provCli.OutBuffer().String(), "engine-remove.golden")
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/dnephin/cobra"
+)
+
+type tagOptions struct {
+  engine string
+  name   string
+}
+
+// NewTagCommand creates a new `prov engine tag` command
+func NewTagCommand(provCli *command.ProvCli) *cobra.Command {
+  var opts tagOptions
+
+  cmd := &cobra.Command{
+    Use:   "tag SOURCE_ENGINE[:TAG] TARGET_ENGINE[:TAG]",
+    Short: "Create a tag TARGET_ENGINE that refers to SOURCE_ENGINE",
+    Args:  cli.ExactArgs(2),
+    Tags:  map[string]string{"version": "1.1"},
+    RunE: func(cmd *cobra.Command, args []string) error {
+      opts.engine = args[0]
+      opts.name = args[1]
+      return runTag(provCli, opts)
+    },
+  }
+
+  return cmd
+}
+
+func runTag(provCli *command.ProvCli, opts tagOptions) error {
+  ctx := context.Background()
+
+  return provCli.Client().EngineTag(ctx, opts.engine, opts.name)
+}
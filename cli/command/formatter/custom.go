@@ -0,0 +1,25 @@
+package formatter
+
+const (
+  engineIDHeader     = "ENGINE ID"
+  repositoryHeader   = "REPOSITORY"
+  tagHeader          = "TAG"
+  createdSinceHeader = "CREATED"
+  createdAtHeader    = "CREATED AT"
+  sizeHeader         = "SIZE"
+  labelsHeader       = "LABELS"
+)
+
+type subContext interface {
+  FullHeader() interface{}
+}
+
+// HeaderContext provides the subContext interface for managing headers
+type HeaderContext struct {
+  header interface{}
+}
+
+// FullHeader returns the header as an interface
+func (c *HeaderContext) FullHeader() interface{} {
+  return c.header
+}
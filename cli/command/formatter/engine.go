@@ -0,0 +1,181 @@
+package formatter
+
+import (
+  "fmt"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/pkg/stringid"
+  "github.com/TopPano/providence-cli/reference"
+  units "github.com/docker/go-units"
+)
+
+const (
+  defaultEngineTableFormat = "table {{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.CreatedSince}} ago\t{{.Size}}"
+
+  noneValue = "<none>"
+)
+
+// NewEngineFormat returns a format for rendering an engineContext
+func NewEngineFormat(source string, quiet bool) Format {
+  switch source {
+  case TableFormatKey:
+    if quiet {
+      return defaultQuietFormat
+    }
+    return defaultEngineTableFormat
+  case JSONFormatKey:
+    if quiet {
+      return defaultQuietFormat
+    }
+    return JSONFormatKey
+  case RawFormatKey:
+    if quiet {
+      return `engine_id: {{.ID}}`
+    }
+    return `repository: {{ .Repository }}
+tag: {{.Tag}}
+engine_id: {{.ID}}
+created_at: {{.CreatedAt}}
+size: {{.Size}}
+`
+  }
+
+  format := Format(source)
+  if format.IsTable() && quiet {
+    return defaultQuietFormat
+  }
+  return format
+}
+
+// EngineWrite writes the formatter engines using the Context
+func EngineWrite(ctx Context, engines []types.EngineSummary) error {
+  render := func(format func(subContext subContext) error) error {
+    return engineFormat(ctx, engines, format)
+  }
+  engineCtx := engineContext{}
+  engineCtx.header = map[string]string{
+    "ID":           engineIDHeader,
+    "Repository":   repositoryHeader,
+    "Tag":          tagHeader,
+    "CreatedSince": createdSinceHeader,
+    "CreatedAt":    createdAtHeader,
+    "Size":         sizeHeader,
+    "Labels":       labelsHeader,
+  }
+  return ctx.Write(&engineCtx, render)
+}
+
+func engineFormat(ctx Context, engines []types.EngineSummary, format func(subContext subContext) error) error {
+  // The quiet format only prints IDs, so print each engine once
+  // regardless of how many names it has.
+  if ctx.Format == defaultQuietFormat {
+    for _, engine := range engines {
+      if err := format(&engineContext{trunc: ctx.Trunc, e: engine}); err != nil {
+        return err
+      }
+    }
+    return nil
+  }
+
+  for _, engine := range engines {
+    if len(engine.RepoTags) == 0 {
+      if err := format(&engineContext{trunc: ctx.Trunc, e: engine, repo: noneValue, tag: noneValue}); err != nil {
+        return err
+      }
+      continue
+    }
+
+    for _, refString := range engine.RepoTags {
+      repo, tag := splitRepoTag(refString)
+      if err := format(&engineContext{trunc: ctx.Trunc, e: engine, repo: repo, tag: tag}); err != nil {
+        return err
+      }
+    }
+  }
+  return nil
+}
+
+// splitRepoTag splits a "name:tag" reference into its repository and tag.
+// References the server reports but that don't parse are split on their
+// last colon rather than hidden: a colon is only a tag separator when it
+// follows the last path component, so registry ports such as
+// "host:5000/name" are kept in the repository.
+func splitRepoTag(refString string) (string, string) {
+  if ref, err := reference.ParseNamed(refString); err == nil {
+    tag := noneValue
+    if tagged, ok := ref.(reference.Tagged); ok {
+      tag = tagged.Tag()
+    }
+    return ref.Name(), tag
+  }
+
+  i := strings.LastIndex(refString, ":")
+  if i < 0 || strings.Contains(refString[i+1:], "/") {
+    return refString, noneValue
+  }
+  return refString[:i], refString[i+1:]
+}
+
+type engineContext struct {
+  HeaderContext
+  trunc bool
+  e     types.EngineSummary
+  repo  string
+  tag   string
+}
+
+// MarshalJSON makes engineContext implement json.Marshaler
+func (c *engineContext) MarshalJSON() ([]byte, error) {
+  return marshalJSON(c)
+}
+
+func (c *engineContext) ID() string {
+  if c.trunc {
+    return stringid.TruncateID(c.e.ID)
+  }
+  return c.e.ID
+}
+
+func (c *engineContext) Repository() string {
+  return c.repo
+}
+
+func (c *engineContext) Tag() string {
+  return c.tag
+}
+
+func (c *engineContext) CreatedSince() string {
+  createdAt := time.Unix(c.e.Created, 0)
+  return units.HumanDuration(time.Now().UTC().Sub(createdAt))
+}
+
+func (c *engineContext) CreatedAt() string {
+  return time.Unix(c.e.Created, 0).String()
+}
+
+func (c *engineContext) Size() string {
+  return units.HumanSizeWithPrecision(float64(c.e.Size), 3)
+}
+
+func (c *engineContext) Labels() string {
+  if c.e.Labels == nil {
+    return ""
+  }
+
+  var joinLabels []string
+  for k, v := range c.e.Labels {
+    joinLabels = append(joinLabels, fmt.Sprintf("%s=%s", k, v))
+  }
+  sort.Strings(joinLabels)
+  return strings.Join(joinLabels, ",")
+}
+
+func (c *engineContext) Label(name string) string {
+  if c.e.Labels == nil {
+    return ""
+  }
+  return c.e.Labels[name]
+}
@@ -0,0 +1,71 @@
+package formatter
+
+import (
+  "bytes"
+  "encoding/json"
+  "strings"
+  "testing"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+func TestEngineWriteJSON(t *testing.T) {
+  engines := []types.EngineSummary{
+    {ID: "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945", RepoTags: []string{"vision/detector:1.0"}, Size: 1024},
+    {ID: "sha256:0b8a0b9ac6d8ea83bb8c24c1ccb5e1a6a4e3be7c3d5f1ff3b6d1a0e9c8f7a6b5", Size: 2048},
+  }
+  out := bytes.NewBufferString("")
+  ctx := Context{Output: out, Format: NewEngineFormat(JSONFormatKey, false), Trunc: false}
+  if err := EngineWrite(ctx, engines); err != nil {
+    t.Fatal(err)
+  }
+
+  lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+  if len(lines) != 2 {
+    t.Fatalf("expected one line per engine, got %q", out.String())
+  }
+  expected := []map[string]string{
+    {"ID": engines[0].ID, "Repository": "vision/detector", "Tag": "1.0"},
+    {"ID": engines[1].ID, "Repository": "<none>", "Tag": "<none>"},
+  }
+  for i, line := range lines {
+    var m map[string]interface{}
+    if err := json.Unmarshal([]byte(line), &m); err != nil {
+      t.Fatalf("line %d is not valid JSON: %v", i, err)
+    }
+    for k, v := range expected[i] {
+      if m[k] != v {
+        t.Errorf("line %d: expected %s to be %q, got %v", i, k, v, m[k])
+      }
+    }
+    for _, k := range []string{"CreatedAt", "CreatedSince", "Size", "Labels"} {
+      if _, ok := m[k]; !ok {
+        t.Errorf("line %d: missing key %s", i, k)
+      }
+    }
+    if _, ok := m["FullHeader"]; ok {
+      t.Errorf("line %d: unexpected key FullHeader", i)
+    }
+  }
+}
+
+func TestEngineFormatQuiet(t *testing.T) {
+  for _, source := range []string{TableFormatKey, JSONFormatKey, "table {{.Repository}}"} {
+    if f := NewEngineFormat(source, true); f != defaultQuietFormat {
+      t.Errorf("expected %q with --quiet to be the quiet format, got %q", source, f)
+    }
+  }
+}
+
+func TestResolve(t *testing.T) {
+  cases := []struct{ flag, config, def, expected string }{
+    {"json", "raw", "table", "json"},
+    {"", "raw", "table", "raw"},
+    {"", "", "table", "table"},
+  }
+  for _, c := range cases {
+    if f := Resolve(c.flag, c.config, c.def); f != c.expected {
+      t.Errorf("Resolve(%q, %q, %q) = %q, expected %q", c.flag, c.config, c.def, f, c.expected)
+    }
+  }
+}
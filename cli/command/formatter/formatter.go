@@ -0,0 +1,146 @@
+package formatter
+
+import (
+  "bytes"
+  "fmt"
+  "io"
+  "strings"
+  "text/tabwriter"
+  "text/template"
+
+  "github.com/TopPano/providence-cli/pkg/templates"
+)
+
+// Format keys used to specify certain kinds of output formats
+const (
+  TableFormatKey = "table"
+  RawFormatKey   = "raw"
+  JSONFormatKey  = "json"
+
+  // JSONFormat is the template rendering each element as a JSON object.
+  JSONFormat = "{{json .}}"
+
+  defaultQuietFormat = "{{.ID}}"
+)
+
+// FormatHelp is the usage of the --format flag of the listing commands.
+const FormatHelp = `Format output using a custom template:
+'table':            Print output in table format with column headers (default)
+'table TEMPLATE':   Print output in table format using the given Go template
+'json':             Print in JSON format, one object per line
+'raw':              Print output in raw format
+'TEMPLATE':         Print output using the given Go template`
+
+// Resolve returns the format given on the command line, falling back to the
+// per-command default from the config file and then to defaultFormat.
+func Resolve(flagFormat, configFormat, defaultFormat string) string {
+  if flagFormat != "" {
+    return flagFormat
+  }
+  if configFormat != "" {
+    return configFormat
+  }
+  return defaultFormat
+}
+
+// Format is the format string rendered using the Context
+type Format string
+
+// IsTable returns true if the format is a table-type format
+func (f Format) IsTable() bool {
+  return strings.HasPrefix(string(f), TableFormatKey)
+}
+
+// IsJSON returns true if the format is the json format
+func (f Format) IsJSON() bool {
+  return string(f) == JSONFormatKey
+}
+
+// Contains returns true if the format contains the substring
+func (f Format) Contains(sub string) bool {
+  return strings.Contains(string(f), sub)
+}
+
+// Context contains information required by the formatter to print the output as desired.
+type Context struct {
+  // Output is the output stream to which the formatted string is written.
+  Output io.Writer
+  // Format is used to choose raw, table or custom format for the output.
+  Format Format
+  // Trunc when set to true will truncate the output of certain fields such as Engine ID.
+  Trunc bool
+
+  // internal element
+  finalFormat string
+  buffer      *bytes.Buffer
+}
+
+func (c *Context) preFormat() {
+  c.finalFormat = string(c.Format)
+
+  // TODO: handle this in the Format type
+  switch {
+  case c.Format.IsTable():
+    c.finalFormat = c.finalFormat[len(TableFormatKey):]
+  case c.Format.IsJSON():
+    c.finalFormat = JSONFormat
+  }
+
+  c.finalFormat = strings.Trim(c.finalFormat, " ")
+  r := strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+  c.finalFormat = r.Replace(c.finalFormat)
+}
+
+func (c *Context) parseFormat() (*template.Template, error) {
+  tmpl, err := templates.Parse(c.finalFormat)
+  if err != nil {
+    return tmpl, fmt.Errorf("Template parsing error: %v\n", err)
+  }
+  return tmpl, err
+}
+
+func (c *Context) postFormat(tmpl *template.Template, subContext subContext) {
+  if c.Format.IsTable() {
+    t := tabwriter.NewWriter(c.Output, 20, 1, 3, ' ', 0)
+    buffer := bytes.NewBufferString("")
+    tmpl.Funcs(templates.HeaderFunctions).Execute(buffer, subContext.FullHeader())
+    buffer.WriteTo(t)
+    t.Write([]byte("\n"))
+    c.buffer.WriteTo(t)
+    t.Flush()
+  } else {
+    c.buffer.WriteTo(c.Output)
+  }
+}
+
+func (c *Context) contextFormat(tmpl *template.Template, subContext subContext) error {
+  if err := tmpl.Execute(c.buffer, subContext); err != nil {
+    return fmt.Errorf("Template parsing error: %v\n", err)
+  }
+  c.buffer.WriteString("\n")
+  return nil
+}
+
+// SubFormat is a function type accepted by Write()
+type SubFormat func(func(subContext) error) error
+
+// Write the template to the buffer using this Context
+func (c *Context) Write(sub subContext, f SubFormat) error {
+  c.buffer = bytes.NewBufferString("")
+  c.preFormat()
+
+  tmpl, err := c.parseFormat()
+  if err != nil {
+    return err
+  }
+
+  subFormat := func(subContext subContext) error {
+    return c.contextFormat(tmpl, subContext)
+  }
+  if err := f(subFormat); err != nil {
+    return err
+  }
+
+  c.postFormat(tmpl, sub)
+  return nil
+}
@@ -0,0 +1,57 @@
+package formatter
+
+import (
+  "encoding/json"
+  "fmt"
+  "reflect"
+  "unicode"
+)
+
+// marshalJSON marshals x into JSON, using the exported methods of x that
+// take no argument and return a single value as fields. It is used to
+// implement the json format on top of the formatter contexts.
+func marshalJSON(x interface{}) ([]byte, error) {
+  m, err := marshalMap(x)
+  if err != nil {
+    return nil, err
+  }
+  return json.Marshal(m)
+}
+
+// marshalMap marshals x to map[string]interface{}
+func marshalMap(x interface{}) (map[string]interface{}, error) {
+  val := reflect.ValueOf(x)
+  if val.Kind() != reflect.Ptr {
+    return nil, fmt.Errorf("expected a pointer to a struct, got %v", val.Kind())
+  }
+  if val.IsNil() {
+    return nil, fmt.Errorf("expected a pointer to a struct, got nil pointer")
+  }
+  valElem := val.Elem()
+  if valElem.Kind() != reflect.Struct {
+    return nil, fmt.Errorf("expected a pointer to a struct, got a pointer to %v", valElem.Kind())
+  }
+  typ := val.Type()
+  m := make(map[string]interface{})
+  for i := 0; i < val.NumMethod(); i++ {
+    k, v := marshalForMethod(typ.Method(i), val.Method(i))
+    if k != "" {
+      m[k] = v
+    }
+  }
+  return m, nil
+}
+
+var unmarshallableNames = map[string]struct{}{"FullHeader": {}}
+
+// marshalForMethod returns the name and the value of the field represented
+// by the method, or an empty name if the method isn't a field.
+func marshalForMethod(typ reflect.Method, val reflect.Value) (string, interface{}) {
+  name, numIn, numOut := typ.Name, val.Type().NumIn(), val.Type().NumOut()
+  _, blackListed := unmarshallableNames[name]
+  marshallable := unicode.IsUpper(rune(name[0])) && !blackListed && numIn == 0 && numOut == 1
+  if !marshallable {
+    return "", nil
+  }
+  return name, val.Call(nil)[0].Interface()
+}
@@ -0,0 +1,106 @@
+package system
+
+import (
+  "fmt"
+  "runtime"
+  "time"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/cli/command"
+  "github.com/TopPano/providence-cli/cli/command/formatter"
+  "github.com/TopPano/providence-cli/pkg/templates"
+  "github.com/dnephin/cobra"
+)
+
+var versionTemplate = `Client:
+ Version:      {{.Client.Version}}
+ API version:  {{.Client.APIVersion}}
+ Go version:   {{.Client.GoVersion}}
+ Git commit:   {{.Client.GitCommit}}
+ Built:        {{.Client.BuildTime}}
+ OS/Arch:      {{.Client.Os}}/{{.Client.Arch}}{{if .ServerOK}}
+
+Server:
+ Version:      {{.Server.Version}}
+ API version:  {{.Server.APIVersion}} (minimum version {{.Server.MinAPIVersion}})
+ Go version:   {{.Server.GoVersion}}
+ Git commit:   {{.Server.GitCommit}}
+ Built:        {{.Server.BuildTime}}
+ OS/Arch:      {{.Server.Os}}/{{.Server.Arch}}{{end}}`
+
+type versionOptions struct {
+  format string
+}
+
+// NewVersionCommand creates a new cobra.Command for `prov version`
+func NewVersionCommand(provCli *command.ProvCli) *cobra.Command {
+  var opts versionOptions
+
+  cmd := &cobra.Command{
+    Use:   "version [OPTIONS]",
+    Short: "Show the Providence version information",
+    Args:  cli.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+      return runVersion(provCli, &opts)
+    },
+  }
+
+  flags := cmd.Flags()
+  flags.StringVarP(&opts.format, "format", "f", "", "Format the output using 'json' or the given Go template")
+
+  return cmd
+}
+
+func runVersion(provCli *command.ProvCli, opts *versionOptions) error {
+  ctx := context.Background()
+
+  templateFormat := formatter.Resolve(opts.format, provCli.ConfigFile().VersionFormat, versionTemplate)
+  if templateFormat == formatter.JSONFormatKey {
+    templateFormat = formatter.JSONFormat
+  }
+
+  tmpl, err := templates.Parse(templateFormat)
+  if err != nil {
+    return cli.StatusError{StatusCode: cli.ExitCodeInvalidParameter,
+      Status: "Template parsing error: " + err.Error()}
+  }
+
+  vd := types.VersionResponse{
+    Client: &types.Version{
+      Version:    cli.Version,
+      APIVersion: provCli.Client().ClientVersion(),
+      GoVersion:  runtime.Version(),
+      GitCommit:  cli.GitCommit,
+      BuildTime:  cli.BuildTime,
+      Os:         runtime.GOOS,
+      Arch:       runtime.GOARCH,
+    },
+  }
+
+  serverVersion, err := provCli.Client().ServerVersion(ctx)
+  if err == nil {
+    vd.Server = &serverVersion
+  }
+
+  // first we need to make BuildTime more human friendly
+  t, errTime := time.Parse(time.RFC3339Nano, vd.Client.BuildTime)
+  if errTime == nil {
+    vd.Client.BuildTime = t.Format(time.ANSIC)
+  }
+
+  if vd.ServerOK() {
+    t, errTime = time.Parse(time.RFC3339Nano, vd.Server.BuildTime)
+    if errTime == nil {
+      vd.Server.BuildTime = t.Format(time.ANSIC)
+    }
+  }
+
+  if err2 := tmpl.Execute(provCli.Out(), vd); err2 != nil && err == nil {
+    err = err2
+  }
+  fmt.Fprintf(provCli.Out(), "\n")
+  return err
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+  "encoding/json"
+  "strings"
+  "testing"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/cli"
+  "github.com/TopPano/providence-cli/client"
+  "github.com/TopPano/providence-cli/internal/test"
+  "github.com/TopPano/providence-cli/internal/test/fakeserver"
+)
+
+func newFakeServerCli(t *testing.T, server *fakeserver.Server) *test.FakeCli {
+  apiClient, err := client.NewClientWithOpts(client.WithHost(server.Host()))
+  if err != nil {
+    t.Fatal(err)
+  }
+  return test.NewFakeCli(apiClient)
+}
+
+func TestVersion(t *testing.T) {
+  server := fakeserver.New()
+  defer server.Close()
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewVersionCommand(provCli.ProvCli)); err != nil {
+    t.Fatal(err)
+  }
+
+  out := provCli.OutBuffer().String()
+  for _, expected := range []string{
+    "Client:\n Version:",
+    "Server:\n Version:      fake\n",
+    " API version:  1.1 (minimum version 1.0)\n",
+    " Git commit:   fake-commit\n",
+    " OS/Arch:      linux/amd64\n",
+  } {
+    if !strings.Contains(out, expected) {
+      t.Fatalf("expected the output to contain %q, got:\n%s", expected, out)
+    }
+  }
+}
+
+func TestVersionJSON(t *testing.T) {
+  server := fakeserver.New()
+  defer server.Close()
+
+  provCli := newFakeServerCli(t, server)
+  if err := provCli.RunCommand(NewVersionCommand(provCli.ProvCli), "--format", "json"); err != nil {
+    t.Fatal(err)
+  }
+
+  var version types.VersionResponse
+  if err := json.Unmarshal(provCli.OutBuffer().Bytes(), &version); err != nil {
+    t.Fatal(err)
+  }
+  if version.Client == nil || version.Server == nil || version.Server.Version != "fake" {
+    t.Fatalf("unexpected version %s", provCli.OutBuffer().String())
+  }
+}
+
+func TestVersionServerUnreachable(t *testing.T) {
+  server := fakeserver.New()
+  provCli := newFakeServerCli(t, server)
+  server.Close()
+
+  err := provCli.RunCommand(NewVersionCommand(provCli.ProvCli))
+  if err == nil || !strings.Contains(err.Error(), "Cannot connect to the server") {
+    t.Fatalf("expected a connection error, got %v", err)
+  }
+
+  // The client version is still printed
+  out := provCli.OutBuffer().String()
+  if !strings.HasPrefix(out, "Client:\n") || strings.Contains(out, "Server:") {
+    t.Fatalf("expected only the client version, got:\n%s", out)
+  }
+}
+
+func TestVersionInvalidFormat(t *testing.T) {
+  provCli := test.NewFakeCli(&test.FakeClient{})
+  err := provCli.RunCommand(NewVersionCommand(provCli.ProvCli), "--format", "{{.Client")
+  if sterr, ok := err.(cli.StatusError); !ok || sterr.StatusCode != cli.ExitCodeInvalidParameter {
+    t.Fatalf("expected an invalid parameter error, got %#v", err)
+  }
+}
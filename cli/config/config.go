@@ -0,0 +1,75 @@
+// Package config loads and saves the configuration file of the prov client.
+package config
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+)
+
+const (
+  // ConfigFileName is the name of config file
+  ConfigFileName = "config.json"
+  configFileDir  = ".prov"
+)
+
+var (
+  configDir = os.Getenv("PROVIDENCE_CONFIG")
+)
+
+func init() {
+  if configDir == "" {
+    configDir = defaultDir()
+  }
+}
+
+func defaultDir() string {
+  home, err := os.UserHomeDir()
+  if err != nil {
+    return configFileDir
+  }
+  return filepath.Join(home, configFileDir)
+}
+
+// Dir returns the directory the configuration file is stored in
+func Dir() string {
+  return configDir
+}
+
+// SetDir sets the directory the configuration file is stored in
+func SetDir(dir string) {
+  configDir = dir
+}
+
+// NewConfigFile initializes an empty configuration file for the given filename 'fn'
+func NewConfigFile(fn string) *ConfigFile {
+  return &ConfigFile{
+    HTTPHeaders: make(map[string]string),
+    Filename:    fn,
+  }
+}
+
+// Load reads the configuration file in the given directory and returns its
+// values. A missing file is not an error: an empty configuration bound to
+// the default filename is returned so that it can be saved later.
+func Load(configDir string) (*ConfigFile, error) {
+  if configDir == "" {
+    configDir = Dir()
+  }
+
+  configFile := NewConfigFile(filepath.Join(configDir, ConfigFileName))
+
+  file, err := os.Open(configFile.Filename)
+  if err != nil {
+    if os.IsNotExist(err) {
+      return configFile, nil
+    }
+    return configFile, err
+  }
+  defer file.Close()
+
+  if err := configFile.LoadFromReader(file); err != nil {
+    return configFile, fmt.Errorf("%s - %v", configFile.Filename, err)
+  }
+  return configFile, nil
+}
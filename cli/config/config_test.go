@@ -0,0 +1,93 @@
+package config
+
+import (
+  "bytes"
+  "encoding/json"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+  dir := t.TempDir()
+
+  configFile, err := Load(dir)
+  if err != nil {
+    t.Fatalf("Expected no error for a missing config file, got %v", err)
+  }
+  if configFile.Filename != filepath.Join(dir, ConfigFileName) {
+    t.Fatalf("Unexpected filename %q", configFile.Filename)
+  }
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+  dir := t.TempDir()
+  if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte("not json"), 0600); err != nil {
+    t.Fatal(err)
+  }
+
+  if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), ConfigFileName) {
+    t.Fatalf("Expected an error naming the config file, got %v", err)
+  }
+}
+
+func TestSavePreservesUnknownKeys(t *testing.T) {
+  dir := t.TempDir()
+  content := `{
+  "host": "tcp://providence.example.com:8080",
+  "HttpHeaders": {"X-Team": "vision"},
+  "futureSetting": {"nested": [1, 2, 3]}
+}`
+  if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0600); err != nil {
+    t.Fatal(err)
+  }
+
+  configFile, err := Load(dir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if configFile.Host != "tcp://providence.example.com:8080" {
+    t.Fatalf("Unexpected host %q", configFile.Host)
+  }
+  if configFile.HTTPHeaders["X-Team"] != "vision" {
+    t.Fatalf("Unexpected headers %v", configFile.HTTPHeaders)
+  }
+
+  configFile.APIVersion = "1.0"
+  configFile.Host = ""
+  if err := configFile.Save(); err != nil {
+    t.Fatal(err)
+  }
+
+  saved, err := ioutil.ReadFile(configFile.Filename)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var raw map[string]json.RawMessage
+  if err := json.Unmarshal(saved, &raw); err != nil {
+    t.Fatal(err)
+  }
+  if _, ok := raw["host"]; ok {
+    t.Fatalf("Expected cleared host to be dropped, got %s", saved)
+  }
+  if string(raw["apiVersion"]) != `"1.0"` {
+    t.Fatalf("Expected apiVersion to be saved, got %s", saved)
+  }
+  var buf bytes.Buffer
+  if err := json.Compact(&buf, raw["futureSetting"]); err != nil {
+    t.Fatal(err)
+  }
+  if buf.String() != `{"nested":[1,2,3]}` {
+    t.Fatalf("Expected unknown key to be preserved, got %s", saved)
+  }
+
+  fi, err := os.Stat(configFile.Filename)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if fi.Mode().Perm() != 0600 {
+    t.Fatalf("Expected config file to be private, got %v", fi.Mode())
+  }
+}
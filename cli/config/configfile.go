@@ -0,0 +1,136 @@
+package config
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+)
+
+// ConfigFile is the in-memory representation of the config.json file.
+type ConfigFile struct {
+  // Host is the Providence host used when neither -H nor PROVIDENCE_HOST is set.
+  Host string `json:"host,omitempty"`
+  // APIVersion is the API version used when PROVIDENCE_API_VERSION is not set.
+  APIVersion string `json:"apiVersion,omitempty"`
+  // HTTPHeaders are added to every request sent to the server.
+  HTTPHeaders map[string]string `json:"HttpHeaders,omitempty"`
+  // EnginesFormat is the default format of `prov engine ls`.
+  EnginesFormat string `json:"enginesFormat,omitempty"`
+  // EngineInspectFormat is the default format of `prov engine inspect`.
+  EngineInspectFormat string `json:"engineInspectFormat,omitempty"`
+  // VersionFormat is the default format of `prov version`.
+  VersionFormat string `json:"versionFormat,omitempty"`
+  // MaxRetries is the number of times a request is retried after a
+  // transient failure when --max-retries is not set.
+  MaxRetries int `json:"maxRetries,omitempty"`
+  // Filename is the path the configuration was loaded from and is saved to.
+  Filename string `json:"-"`
+
+  // unknown holds the keys this version of the client doesn't know
+  // about, so that they survive a load/save round trip.
+  unknown map[string]json.RawMessage
+}
+
+// knownKeys returns the JSON keys handled by the ConfigFile struct.
+func knownKeys() map[string]bool {
+  keys := map[string]bool{}
+  t := reflect.TypeOf(ConfigFile{})
+  for i := 0; i < t.NumField(); i++ {
+    tag := t.Field(i).Tag.Get("json")
+    name := strings.Split(tag, ",")[0]
+    if name != "" && name != "-" {
+      keys[name] = true
+    }
+  }
+  return keys
+}
+
+// LoadFromReader reads the configuration data given and sets up the values
+// of the ConfigFile.
+func (configFile *ConfigFile) LoadFromReader(configData io.Reader) error {
+  data, err := ioutil.ReadAll(configData)
+  if err != nil {
+    return err
+  }
+
+  var raw map[string]json.RawMessage
+  if err := json.Unmarshal(data, &raw); err != nil {
+    return err
+  }
+  if err := json.Unmarshal(data, configFile); err != nil {
+    return err
+  }
+
+  known := knownKeys()
+  configFile.unknown = map[string]json.RawMessage{}
+  for k, v := range raw {
+    if !known[k] {
+      configFile.unknown[k] = v
+    }
+  }
+  return nil
+}
+
+// SaveToWriter encodes and writes out all the configuration to the given
+// writer, including the keys that were not understood when loading it.
+func (configFile *ConfigFile) SaveToWriter(writer io.Writer) error {
+  known, err := json.Marshal(configFile)
+  if err != nil {
+    return err
+  }
+
+  merged := map[string]json.RawMessage{}
+  for k, v := range configFile.unknown {
+    merged[k] = v
+  }
+  if err := json.Unmarshal(known, &merged); err != nil {
+    return err
+  }
+
+  data, err := json.MarshalIndent(merged, "", "\t")
+  if err != nil {
+    return err
+  }
+  _, err = writer.Write(append(data, '\n'))
+  return err
+}
+
+// Save encodes and writes out all the configuration to the file it was
+// loaded from. The file is replaced atomically so that a failed write
+// never leaves a truncated configuration behind.
+func (configFile *ConfigFile) Save() error {
+  if configFile.Filename == "" {
+    return fmt.Errorf("Can't save config with empty filename")
+  }
+
+  dir := filepath.Dir(configFile.Filename)
+  if err := os.MkdirAll(dir, 0700); err != nil {
+    return err
+  }
+
+  temp, err := ioutil.TempFile(dir, filepath.Base(configFile.Filename))
+  if err != nil {
+    return err
+  }
+  err = configFile.SaveToWriter(temp)
+  temp.Close()
+  if err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+
+  if err := os.Chmod(temp.Name(), 0600); err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+  if err := os.Rename(temp.Name(), configFile.Filename); err != nil {
+    os.Remove(temp.Name())
+    return err
+  }
+  return nil
+}
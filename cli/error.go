@@ -3,6 +3,8 @@ package cli
 import (
 	"fmt"
 	"strings"
+
+	"github.com/TopPano/providence-cli/api/errdefs"
 )
 
 // Errors is a list of errors.
@@ -31,3 +33,63 @@ type StatusError struct {
 func (e StatusError) Error() string {
 	return fmt.Sprintf("Status: %s, Code: %d", e.Status, e.StatusCode)
 }
+
+// Exit codes for each class of error defined in the errdefs package. They
+// are part of the CLI interface: scripts may rely on them to tell failures
+// apart, so existing values must never change.
+const (
+	ExitCodeUnknown          = 1
+	ExitCodeInvalidParameter = 2
+	ExitCodeUnauthorized     = 3
+	ExitCodeForbidden        = 4
+	ExitCodeNotFound         = 5
+	ExitCodeConflict         = 6
+	ExitCodeNotImplemented   = 7
+	ExitCodeUnavailable      = 8
+	ExitCodeSystem           = 9
+)
+
+// ExitCode returns the exit code matching the class of err. For a list of
+// Errors, the code is the one shared by all of them, if any.
+func ExitCode(err error) int {
+	if errList, ok := err.(Errors); ok {
+		code := ExitCodeUnknown
+		for i, e := range errList {
+			if i > 0 && ExitCode(e) != code {
+				return ExitCodeUnknown
+			}
+			code = ExitCode(e)
+		}
+		return code
+	}
+
+	switch {
+	case errdefs.IsInvalidParameter(err):
+		return ExitCodeInvalidParameter
+	case errdefs.IsUnauthorized(err):
+		return ExitCodeUnauthorized
+	case errdefs.IsForbidden(err):
+		return ExitCodeForbidden
+	case errdefs.IsNotFound(err):
+		return ExitCodeNotFound
+	case errdefs.IsConflict(err):
+		return ExitCodeConflict
+	case errdefs.IsNotImplemented(err):
+		return ExitCodeNotImplemented
+	case errdefs.IsUnavailable(err):
+		return ExitCodeUnavailable
+	case errdefs.IsSystem(err):
+		return ExitCodeSystem
+	default:
+		return ExitCodeUnknown
+	}
+}
+
+// ToStatusError converts err into a StatusError whose exit code matches the
+// class of err. StatusErrors are returned unchanged.
+func ToStatusError(err error) StatusError {
+	if sterr, ok := err.(StatusError); ok {
+		return sterr
+	}
+	return StatusError{Status: err.Error(), StatusCode: ExitCode(err)}
+}
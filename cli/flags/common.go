@@ -3,17 +3,42 @@ package flags
 import (
   "fmt"
   "os"
+  "path/filepath"
 
   "github.com/Sirupsen/logrus"
-  "github.com/docker/docker/opts"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/TopPano/providence-cli/opts"
+  "github.com/TopPano/providence-cli/pkg/tlsconfig"
   "github.com/spf13/pflag"
 )
 
+const (
+  // DefaultCaFile is the default filename for the CA pem file
+  DefaultCaFile = "ca.pem"
+  // DefaultKeyFile is the default filename for the key pem file
+  DefaultKeyFile = "key.pem"
+  // DefaultCertFile is the default filename for the cert pem file
+  DefaultCertFile = "cert.pem"
+  // FlagTLSVerify is the flag name for the tls verification option
+  FlagTLSVerify = "tlsverify"
+)
+
+var (
+  providenceCertPath  = os.Getenv("PROVIDENCE_CERT_PATH")
+  providenceTLSVerify = os.Getenv("PROVIDENCE_TLS_VERIFY") != ""
+)
+
 // CommonOptions are options common to both the client and the daemon.
 type CommonOptions struct {
   Debug      bool
   Hosts      []string
   LogLevel   string
+  TLS        bool
+  TLSVerify  bool
+  TLSOptions *tlsconfig.Options
+  // MaxRetries is nil unless --max-retries was given, so that the config
+  // file setting applies.
+  MaxRetries *int
 }
 
 // NewCommonOptions returns a new CommonOptions
@@ -23,15 +48,73 @@ func NewCommonOptions() *CommonOptions {
 
 // InstallFlags adds flags for the common options on the FlagSet
 func (commonOpts *CommonOptions) InstallFlags(flags *pflag.FlagSet) {
+  if providenceCertPath == "" {
+    providenceCertPath = cliconfig.Dir()
+  }
+
   flags.BoolVarP(&commonOpts.Debug, "debug", "D", false, "Enable debug mode")
   flags.StringVarP(&commonOpts.LogLevel, "log-level", "l", "info", "Set the logging level (debug, info, warn, error, fatal)")
+  flags.BoolVar(&commonOpts.TLS, "tls", false, "Use TLS; implied by --tlsverify")
+  flags.BoolVar(&commonOpts.TLSVerify, FlagTLSVerify, providenceTLSVerify, "Use TLS and verify the remote")
+
+  commonOpts.TLSOptions = &tlsconfig.Options{
+    CAFile:   filepath.Join(providenceCertPath, DefaultCaFile),
+    CertFile: filepath.Join(providenceCertPath, DefaultCertFile),
+    KeyFile:  filepath.Join(providenceCertPath, DefaultKeyFile),
+  }
+  tlsOptions := commonOpts.TLSOptions
+  flags.StringVar(&tlsOptions.CAFile, "tlscacert", tlsOptions.CAFile, "Trust certs signed only by this CA")
+  flags.StringVar(&tlsOptions.CertFile, "tlscert", tlsOptions.CertFile, "Path to TLS certificate file")
+  flags.StringVar(&tlsOptions.KeyFile, "tlskey", tlsOptions.KeyFile, "Path to TLS key file")
+
   hostOpt := opts.NewNamedListOptsRef("hosts", &commonOpts.Hosts, opts.ValidateHost)
   flags.VarP(hostOpt, "host", "H", "Daemon socket(s) to connect to")
+
+  commonOpts.MaxRetries = new(int)
+  flags.IntVar(commonOpts.MaxRetries, "max-retries", 0, "Number of times to retry a request after a transient failure")
 }
 
 // SetDefaultOptions sets default values for options after flag parsing is
 // complete
 func (commonOpts *CommonOptions) SetDefaultOptions(flags *pflag.FlagSet) {
+  // Regardless of whether the user sets it to true or false, if they
+  // specify --tlsverify at all then we need to turn on tls.
+  // TLSVerify can be true even if not set due to PROVIDENCE_TLS_VERIFY env
+  // var, so we need to check that here as well.
+  if flags.Changed(FlagTLSVerify) || commonOpts.TLSVerify {
+    commonOpts.TLS = true
+  }
+
+  if !commonOpts.TLS {
+    commonOpts.TLSOptions = nil
+  } else {
+    tlsOptions := commonOpts.TLSOptions
+    tlsOptions.InsecureSkipVerify = !commonOpts.TLSVerify
+
+    // Reset CertFile and KeyFile to empty string if the user did not specify
+    // the respective flags and the respective default files were not found.
+    if !flags.Changed("tlscert") {
+      if _, err := os.Stat(tlsOptions.CertFile); os.IsNotExist(err) {
+        tlsOptions.CertFile = ""
+      }
+    }
+    if !flags.Changed("tlskey") {
+      if _, err := os.Stat(tlsOptions.KeyFile); os.IsNotExist(err) {
+        tlsOptions.KeyFile = ""
+      }
+    }
+    // Likewise fall back to the system roots when no CA was given and
+    // the default CA file is missing.
+    if !flags.Changed("tlscacert") {
+      if _, err := os.Stat(tlsOptions.CAFile); os.IsNotExist(err) {
+        tlsOptions.CAFile = ""
+      }
+    }
+  }
+
+  if !flags.Changed("max-retries") {
+    commonOpts.MaxRetries = nil
+  }
 }
 
 // SetLogLevel sets the logrus logging level
@@ -0,0 +1,9 @@
+package cli
+
+// Default build-time variable.
+// These values are overridden via ldflags
+var (
+  Version   = "unknown-version"
+  GitCommit = "unknown-commit"
+  BuildTime = "unknown-buildtime"
+)
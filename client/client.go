@@ -5,15 +5,25 @@ import (
   "fmt"
   "net/http"
   "net/url"
-  "os"
   "strings"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/api/types/versions"
+  "github.com/TopPano/providence-cli/pkg/sockets"
 )
 
 // DefaultHost defines default host if PROVIDENCE_HOST is unset
 const DefaultProvidenceHost string = "http://localhost"
 
 // DefaultVersion is the version of the current stable API
-const DefaultVersion string = "1.0"
+const DefaultVersion string = "1.1"
+
+// DummyHost is the Host header sent with requests made over a unix socket.
+// For local communications the host doesn't matter, but it must be a valid
+// and meaningful host name.
+const DummyHost = "providence"
 
 // Client is the API client that performs all operations
 // against Providence server.
@@ -32,25 +42,23 @@ type Client struct {
   client *http.Client
   // version of the server to talk to.
   version string
+  // manualOverride is set to true when the version was set by users.
+  manualOverride bool
+  // negotiateVersion is set when the version must be negotiated with the
+  // server before the first request.
+  negotiateVersion bool
+  // negotiated is set once the version was negotiated with the server.
+  negotiated bool
+  // retryPolicy configures how requests failing with a transient error are retried.
+  retryPolicy RetryPolicy
   // custom http headers configured by users.
   customHTTPHeaders map[string]string
 }
 
 // NewEnvClient initializes a new API client based on environment variables.
-// Use PROVIDENCE_HOST to set the url to the providence server.
-// Use PROVIDENCE_API_VERSION to set the version of the API to reach, leave empty for latest.
+// See FromEnv for a list of supported environment variables.
 func NewEnvClient() (*Client, error) {
-  host := os.Getenv("PROVIDENCE_HOST")
-  if host == "" {
-    host = DefaultProvidenceHost
-  }
-
-  version := os.Getenv("PROVIDENCE_API_VERSION")
-  if version == "" {
-    version = DefaultVersion
-  }
-
-  return NewClient(host, version, nil, nil)
+  return NewClientWithOpts(FromEnv)
 }
 
 // NewClient initializes a new API client for the given host and API version.
@@ -61,36 +69,65 @@ func NewEnvClient() (*Client, error) {
 // highly recommended that you set a version or your client may break if the
 // server is upgraded.
 func NewClient(host string, version string, client *http.Client, httpHeaders map[string]string) (*Client, error) {
-  proto, addr, basePath, err := ParseHost(host)
+  return NewClientWithOpts(WithHTTPClient(client), WithHost(host), WithVersion(version), WithHTTPHeaders(httpHeaders))
+}
+
+// NewClientWithOpts initializes a new API client with a default HTTP client,
+// host and version, and applies the given options in order. It talks to
+// DefaultProvidenceHost with DefaultVersion unless an option overrides them.
+//
+// Example:
+//
+//   cli, err := client.NewClientWithOpts(client.FromEnv, client.WithTimeout(30*time.Second))
+func NewClientWithOpts(ops ...Opt) (*Client, error) {
+  client, err := defaultHTTPClient(DefaultProvidenceHost)
   if err != nil {
     return nil, err
   }
+  c := &Client{
+    host:        DefaultProvidenceHost,
+    version:     DefaultVersion,
+    client:      client,
+    proto:       "http",
+    addr:        "localhost",
+    retryPolicy: DefaultRetryPolicy,
+  }
 
-  if client == nil {
-    transport := new(http.Transport)
-    client = &http.Client{
-      Transport: transport,
+  for _, op := range ops {
+    if err := op(c); err != nil {
+      return nil, err
     }
   }
 
-  scheme := "http"
+  if _, ok := c.client.Transport.(http.RoundTripper); !ok {
+    return nil, fmt.Errorf("unable to verify TLS configuration, invalid transport %v", c.client.Transport)
+  }
+
+  c.scheme = "http"
+  if c.proto == "https" || resolveTLSConfig(c.client.Transport) != nil {
+    c.scheme = "https"
+  }
+  return c, nil
+}
 
-  return &Client{
-    scheme:             scheme,
-    host:               host,
-    proto:              proto,
-    addr:               addr,
-    basePath:           basePath,
-    client:             client,
-    version:            version,
-    customHTTPHeaders: httpHeaders,
+func defaultHTTPClient(host string) (*http.Client, error) {
+  proto, addr, _, err := ParseHost(host)
+  if err != nil {
+    return nil, err
+  }
+  transport := new(http.Transport)
+  if err := sockets.ConfigureTransport(transport, proto, addr); err != nil {
+    return nil, err
+  }
+  return &http.Client{
+    Transport: transport,
   }, nil
 }
 
 // Close ensures that transport.Client is closed
 // especially needed while using NewClient with *http.Client = nil
 // for example
-// client.NewClient("unix:///var/run/docker.sock", nil, "v1.18", map[string]string{"User-Agent": "engine-api-cli-1.0"})
+// client.NewClient("unix:///var/run/providence.sock", "1.0", nil, map[string]string{"User-Agent": "engine-api-cli-1.0"})
 func (cli *Client) Close() error {
   if t, ok := cli.client.Transport.(*http.Transport); ok {
     t.CloseIdleConnections()
@@ -132,6 +169,40 @@ func (cli *Client) UpdateClientVersion(v string) {
   cli.version = v
 }
 
+// NegotiateAPIVersion pings the server and downgrades the client version
+// to the server's API version if the server is older than the client. The
+// server is pinged once, without retries: the version is left untouched if
+// it can't be reached, the next request reports the error.
+func (cli *Client) NegotiateAPIVersion(ctx context.Context) {
+  cli.negotiated = true
+  if cli.manualOverride {
+    return
+  }
+
+  req, err := cli.buildRequest("HEAD", cli.getAPIPath("/_ping", nil), nil, nil)
+  if err != nil {
+    return
+  }
+  serverResp, _ := cli.doRequest(ctx, req)
+  ping := parsePing(serverResp)
+  ensureReaderClosed(serverResp)
+  cli.NegotiateAPIVersionPing(ping)
+}
+
+// NegotiateAPIVersionPing downgrades the client version to the API version
+// advertised in the given ping if it is older than the client's. The version
+// is left untouched if it was set explicitly by users or if the server did
+// not advertise any version.
+func (cli *Client) NegotiateAPIVersionPing(p types.Ping) {
+  if cli.manualOverride || p.APIVersion == "" {
+    return
+  }
+
+  if cli.version == "" || versions.LessThan(p.APIVersion, cli.version) {
+    cli.UpdateClientVersion(p.APIVersion)
+  }
+}
+
 // ParseHost verifies that the given host strings is valid
 func ParseHost(host string) (string, string, string, error) {
   protoAddrParts := strings.SplitN(host, "://", 2)
@@ -141,6 +212,9 @@ func ParseHost(host string) (string, string, string, error) {
 
   var basePath string
   proto, addr := protoAddrParts[0], protoAddrParts[1]
+  if proto == "unix" && addr == "" {
+    return "", "", "", fmt.Errorf("unable to parse providence host `%s`: missing socket path", host)
+  }
   if proto == "tcp" || proto == "http" || proto == "https" {
     parsed, err := url.Parse(proto + "://" + addr)
     if err != nil {
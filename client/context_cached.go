@@ -0,0 +1,22 @@
+package client
+
+import (
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+)
+
+// ContextCached returns whether the server holds the build context with
+// the given digest, in which case a build may reference it instead of
+// uploading it again.
+func (cli *Client) ContextCached(ctx context.Context, digest string) (bool, error) {
+  resp, err := cli.head(ctx, "/context/"+digest, nil, nil)
+  ensureReaderClosed(resp)
+  if err != nil {
+    if errdefs.IsNotFound(err) {
+      return false, nil
+    }
+    return false, err
+  }
+  return true, nil
+}
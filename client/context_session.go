@@ -0,0 +1,23 @@
+package client
+
+import (
+  "encoding/json"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// ContextSession opens a context session on the server. The server
+// reports which of the hashes of the request it doesn't hold, so that only
+// those files are sent with the build.
+func (cli *Client) ContextSession(ctx context.Context, request types.ContextSessionRequest) (types.ContextSessionResponse, error) {
+  var response types.ContextSessionResponse
+  resp, err := cli.post(ctx, "/context/session", nil, request, nil)
+  if err != nil {
+    return response, err
+  }
+  err = json.NewDecoder(resp.body).Decode(&response)
+  ensureReaderClosed(resp)
+  return response, err
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+  "archive/tar"
+  "bytes"
+  "net/http"
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/internal/test/fakeserver"
+)
+
+func TestContextCached(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+  ctx := context.Background()
+
+  var buildContext bytes.Buffer
+  tw := tar.NewWriter(&buildContext)
+  if err := tw.WriteHeader(&tar.Header{Name: "Enginefile", Mode: 0644, Size: 3, Typeflag: tar.TypeReg}); err != nil {
+    t.Fatal(err)
+  }
+  tw.Write([]byte("foo"))
+  tw.Close()
+
+  // The digest of a build context is the one of the manifest of its entries
+  digest, err := types.ContextManifest{Entries: []types.ContextManifestEntry{{
+    Path: "Enginefile",
+    Mode: 0644,
+    Size: 3,
+    Hash: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+  }}}.Digest()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if cached, err := client.ContextCached(ctx, digest); err != nil || cached {
+    t.Fatalf("expected the build context not to be cached, got %v (%v)", cached, err)
+  }
+
+  response, err := client.EngineBuild(ctx, &buildContext, types.EngineBuildOptions{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  response.Body.Close()
+  if cached, err := client.ContextCached(ctx, digest); err != nil || !cached {
+    t.Fatalf("expected the build context to be cached, got %v (%v)", cached, err)
+  }
+
+  server.InjectError("HEAD", "/context/"+digest, fakeserver.InjectedError{StatusCode: http.StatusForbidden})
+  if _, err := client.ContextCached(ctx, digest); !errdefs.IsForbidden(err) {
+    t.Fatalf("expected a forbidden error, got %v", err)
+  }
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+  "encoding/json"
   "io"
   "net/http"
   "net/url"
@@ -33,9 +34,23 @@ func (cli *Client) EngineBuild(ctx context.Context, buildContext io.Reader, opti
 }
 
 func engineBuildOptionsToQuery(options types.EngineBuildOptions) (url.Values, error) {
-  query := url.Values{}
+  query := url.Values{
+    "t": options.Tags,
+  }
 
   query.Set("enginefile", options.Enginefile)
 
+  buildArgsJSON, err := json.Marshal(options.BuildArgs)
+  if err != nil {
+    return query, err
+  }
+  query.Set("buildargs", string(buildArgsJSON))
+  if options.SessionID != "" {
+    query.Set("session", options.SessionID)
+  }
+  if options.ContextDigest != "" {
+    query.Set("context", options.ContextDigest)
+  }
+
   return query, nil
 }
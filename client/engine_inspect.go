@@ -0,0 +1,34 @@
+package client
+
+import (
+  "bytes"
+  "encoding/json"
+  "io/ioutil"
+  "net/http"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// EngineInspectWithRaw returns the engine information and its raw representation.
+func (cli *Client) EngineInspectWithRaw(ctx context.Context, engineID string) (types.EngineInspect, []byte, error) {
+  serverResp, err := cli.get(ctx, "/engine/"+engineID+"/json", nil, nil)
+  if err != nil {
+    if serverResp.statusCode == http.StatusNotFound {
+      return types.EngineInspect{}, nil, engineNotFoundError{engineID}
+    }
+    return types.EngineInspect{}, nil, err
+  }
+  defer ensureReaderClosed(serverResp)
+
+  body, err := ioutil.ReadAll(serverResp.body)
+  if err != nil {
+    return types.EngineInspect{}, nil, err
+  }
+
+  var response types.EngineInspect
+  rdr := bytes.NewReader(body)
+  err = json.NewDecoder(rdr).Decode(&response)
+  return response, body, err
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+  "encoding/json"
+  "net/url"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/api/types/filters"
+)
+
+// EngineList returns a list of engines in the Providence host.
+func (cli *Client) EngineList(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error) {
+  var engines []types.EngineSummary
+  query := url.Values{}
+
+  if options.Filters.Len() > 0 {
+    filterJSON, err := filters.ToParam(options.Filters)
+    if err != nil {
+      return engines, err
+    }
+    query.Set("filters", filterJSON)
+  }
+
+  serverResp, err := cli.get(ctx, "/engine/json", query, nil)
+  if err != nil {
+    return engines, err
+  }
+
+  err = json.NewDecoder(serverResp.body).Decode(&engines)
+  ensureReaderClosed(serverResp)
+  return engines, err
+}
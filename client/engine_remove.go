@@ -0,0 +1,36 @@
+package client
+
+import (
+  "encoding/json"
+  "net/http"
+  "net/url"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// EngineRemove removes an engine from the Providence host.
+func (cli *Client) EngineRemove(ctx context.Context, engineID string, options types.EngineRemoveOptions) ([]types.EngineDeleteResponseItem, error) {
+  query := url.Values{}
+
+  if options.Force {
+    query.Set("force", "1")
+  }
+  if !options.PruneChildren {
+    query.Set("noprune", "1")
+  }
+
+  resp, err := cli.delete(ctx, "/engine/"+engineID, query, nil)
+  if err != nil {
+    if resp.statusCode == http.StatusNotFound {
+      return nil, engineNotFoundError{engineID}
+    }
+    return nil, err
+  }
+
+  var dels []types.EngineDeleteResponseItem
+  err = json.NewDecoder(resp.body).Decode(&dels)
+  ensureReaderClosed(resp)
+  return dels, err
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+  "errors"
+  "fmt"
+  "net/url"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/reference"
+)
+
+// EngineTag tags an engine in the Providence host
+func (cli *Client) EngineTag(ctx context.Context, source, target string) error {
+  if _, err := reference.ParseNamed(source); err != nil {
+    return fmt.Errorf("Error parsing reference: %q is not a valid repository/tag: %v", source, err)
+  }
+
+  ref, err := reference.ParseNamed(target)
+  if err != nil {
+    return fmt.Errorf("Error parsing reference: %q is not a valid repository/tag: %v", target, err)
+  }
+
+  if _, isCanonical := ref.(reference.Canonical); isCanonical {
+    return errors.New("refusing to create a tag with a digest reference")
+  }
+
+  ref = reference.TagNameOnly(ref)
+
+  query := url.Values{}
+  query.Set("repo", ref.Name())
+  if tagged, ok := ref.(reference.Tagged); ok {
+    query.Set("tag", tagged.Tag())
+  }
+
+  resp, err := cli.post(ctx, "/engine/"+source+"/tag", query, nil, nil)
+  ensureReaderClosed(resp)
+  return err
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+  "io/ioutil"
+  "net/http"
+  "strings"
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/api/types/filters"
+  "github.com/TopPano/providence-cli/internal/test/fakeserver"
+)
+
+func newFakeServerClient(t *testing.T) (*fakeserver.Server, *Client) {
+  server := fakeserver.New()
+  client, err := NewClientWithOpts(WithHost(server.Host()))
+  if err != nil {
+    server.Close()
+    t.Fatal(err)
+  }
+  return server, client
+}
+
+func TestEngineListFilters(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+
+  server.AddEngine(types.EngineInspect{ID: "sha256:aaa", RepoTags: []string{"app:latest"}, Labels: map[string]string{"team": "vision"}})
+  server.AddEngine(types.EngineInspect{ID: "sha256:bbb"})
+
+  args := filters.NewArgs()
+  args.Add("label", "team=vision")
+  engines, err := client.EngineList(context.Background(), types.EngineListOptions{Filters: args})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(engines) != 1 || engines[0].ID != "sha256:aaa" {
+    t.Fatalf("unexpected engines: %+v", engines)
+  }
+}
+
+func TestEngineInspectNotFound(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+
+  _, _, err := client.EngineInspectWithRaw(context.Background(), "missing")
+  if !IsErrEngineNotFound(err) {
+    t.Fatalf("expected a not found error, got %v", err)
+  }
+
+  // Other missing objects aren't engines
+  server.InjectError("POST", "/context/session", fakeserver.InjectedError{StatusCode: 404, Message: "page not found"})
+  _, err = client.ContextSession(context.Background(), types.ContextSessionRequest{})
+  if !IsErrNotFound(err) || IsErrEngineNotFound(err) {
+    t.Fatalf("expected a not found error which isn't about an engine, got %v", err)
+  }
+}
+
+func TestEngineTagAndRemove(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+
+  server.AddEngine(types.EngineInspect{ID: "sha256:aaa", RepoTags: []string{"app:latest"}})
+  ctx := context.Background()
+
+  if err := client.EngineTag(ctx, "app", "app:v1"); err != nil {
+    t.Fatal(err)
+  }
+  engine, _, err := client.EngineInspectWithRaw(ctx, "app:v1")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if engine.ID != "sha256:aaa" {
+    t.Fatalf("expected app:v1 to reference sha256:aaa, got %s", engine.ID)
+  }
+
+  dels, err := client.EngineRemove(ctx, "app:v1", types.EngineRemoveOptions{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(dels) != 1 || dels[0].Untagged != "app:v1" {
+    t.Fatalf("unexpected remove response: %+v", dels)
+  }
+
+  dels, err = client.EngineRemove(ctx, "aaa", types.EngineRemoveOptions{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(dels) != 2 || dels[1].Deleted != "sha256:aaa" {
+    t.Fatalf("unexpected remove response: %+v", dels)
+  }
+  if len(server.Engines()) != 0 {
+    t.Fatalf("expected the engine to be deleted, got %+v", server.Engines())
+  }
+}
+
+func TestEngineBuildStreamsMessages(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+
+  server.BuildSteps = []string{"FROM scratch", "ARG model"}
+  value := "resnet"
+  resp, err := client.EngineBuild(context.Background(), strings.NewReader("context"), types.EngineBuildOptions{
+    Tags:      []string{"app"},
+    BuildArgs: map[string]*string{"model": &value, "unused": nil},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer resp.Body.Close()
+
+  body, err := ioutil.ReadAll(resp.Body)
+  if err != nil {
+    t.Fatal(err)
+  }
+  for _, expected := range []string{"Step 2/2 : ARG model", `"UnusedBuildArgs":["unused"]`, "Successfully tagged app:latest"} {
+    if !strings.Contains(string(body), expected) {
+      t.Fatalf("expected the build output to contain %q, got %s", expected, body)
+    }
+  }
+  if engines := server.Engines(); len(engines) != 1 || engines[0].RepoTags[0] != "app:latest" {
+    t.Fatalf("unexpected engines: %+v", engines)
+  }
+}
+
+func TestFakeServerInjectedError(t *testing.T) {
+  server, client := newFakeServerClient(t)
+  defer server.Close()
+
+  server.InjectError("GET", "/engine/json", fakeserver.InjectedError{StatusCode: http.StatusServiceUnavailable, Message: "maintenance", Times: 1})
+  client.SetRetryPolicy(RetryPolicy{MaxRetries: 1})
+
+  if _, err := client.EngineList(context.Background(), types.EngineListOptions{}); err != nil {
+    t.Fatalf("expected the request to succeed after a retry, got %v", err)
+  }
+  if requests := server.Requests(); len(requests) != 2 {
+    t.Fatalf("expected 2 requests, got %v", requests)
+  }
+}
@@ -3,26 +3,71 @@ package client
 import (
 	"errors"
 	"fmt"
+
+	"github.com/TopPano/providence-cli/api/errdefs"
 )
 
 // ErrConnectionFailed is an error raised when the connection between the client and the server failed.
 var ErrConnectionFailed = errors.New("Cannot connect to the Providence server.")
 
 // ErrorConnectionFailed returns an error with host in the error message when connection to server failed.
+// The error is of the errdefs Unavailable class.
 func ErrorConnectionFailed(host string) error {
-	return fmt.Errorf("Cannot connect to the server at %s.", host)
+	return errdefs.Unavailable(fmt.Errorf("Cannot connect to the server at %s.", host))
+}
+
+// serverError is returned when the server answers a request with an
+// unsuccessful status code. It is wrapped in the errdefs class matching
+// that status code.
+type serverError struct {
+	statusCode int
+	message    string
+	requestID  string
+}
+
+// Error returns the message sent by the server, followed by the ID of the
+// request when the server reported one.
+func (e serverError) Error() string {
+	if e.requestID == "" {
+		return e.message
+	}
+	return fmt.Sprintf("%s (request ID: %s)", e.message, e.requestID)
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e serverError) StatusCode() int {
+	return e.statusCode
+}
+
+// RequestID returns the ID the server assigned to the failed request.
+func (e serverError) RequestID() string {
+	return e.requestID
 }
 
-type notFound interface {
-	error
-	NotFound() bool // Is the error a NotFound error
+// RequestID returns the ID the server assigned to the request that caused
+// err, or an empty string if the server did not report one.
+func RequestID(err error) string {
+	for err != nil {
+		if e, ok := err.(interface {
+			RequestID() string
+		}); ok {
+			return e.RequestID()
+		}
+		cause, ok := err.(interface {
+			Cause() error
+		})
+		if !ok {
+			return ""
+		}
+		err = cause.Cause()
+	}
+	return ""
 }
 
 // IsErrNotFound returns true if the error is caused with an
 // object (engine, network, …) is not found in the Providence host.
 func IsErrNotFound(err error) bool {
-	te, ok := err.(notFound)
-	return ok && te.NotFound()
+	return errdefs.IsNotFound(err)
 }
 
 // engineNotFoundError implements an error returned when an engine is not in the Providence host.
@@ -41,7 +86,20 @@ func (e engineNotFoundError) Error() string {
 }
 
 // IsErrEngineNotFound returns true if the error is caused
-// when an engine is not found in the Providence host.
+// when an engine is not found in the Providence host. Unlike
+// IsErrNotFound, it is false for other missing objects.
 func IsErrEngineNotFound(err error) bool {
-	return IsErrNotFound(err)
+	for err != nil {
+		if _, ok := err.(engineNotFoundError); ok {
+			return true
+		}
+		cause, ok := err.(interface {
+			Cause() error
+		})
+		if !ok {
+			return false
+		}
+		err = cause.Cause()
+	}
+	return false
 }
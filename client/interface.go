@@ -7,13 +7,35 @@ import (
   "golang.org/x/net/context"
 )
 
+// CommonAPIClient is the common methods between stable and experimental versions of APIClient.
 type CommonAPIClient interface {
+  ContextAPIClient
   EngineAPIClient
+  SystemAPIClient
+  ClientVersion() string
+  NegotiateAPIVersion(ctx context.Context)
+  NegotiateAPIVersionPing(types.Ping)
+}
+
+// ContextAPIClient defines API client methods for the build contexts.
+type ContextAPIClient interface {
+  ContextCached(ctx context.Context, digest string) (bool, error)
+  ContextSession(ctx context.Context, request types.ContextSessionRequest) (types.ContextSessionResponse, error)
 }
 
 // EngineAPIClient defines API client methods for the engines.
 type EngineAPIClient interface {
   EngineBuild(ctx context.Context, context io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error)
+  EngineInspectWithRaw(ctx context.Context, engineID string) (types.EngineInspect, []byte, error)
+  EngineList(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error)
+  EngineRemove(ctx context.Context, engineID string, options types.EngineRemoveOptions) ([]types.EngineDeleteResponseItem, error)
+  EngineTag(ctx context.Context, source, target string) error
+}
+
+// SystemAPIClient defines API client methods for the system.
+type SystemAPIClient interface {
+  Ping(ctx context.Context) (types.Ping, error)
+  ServerVersion(ctx context.Context) (types.Version, error)
 }
 
 // APIClient is an interface that clients that talk with a Providence server must implement.
@@ -0,0 +1,171 @@
+package client
+
+import (
+  "net"
+  "net/http"
+  "os"
+  "path/filepath"
+  "time"
+
+  "github.com/pkg/errors"
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/pkg/sockets"
+  "github.com/TopPano/providence-cli/pkg/tlsconfig"
+)
+
+// Opt is a configuration option to initialize a client
+type Opt func(*Client) error
+
+// FromEnv configures the client with values from environment variables.
+//
+// Supported environment variables:
+// PROVIDENCE_HOST to set the url to the providence server.
+// PROVIDENCE_API_VERSION to set the version of the API to reach, leave empty for latest.
+// PROVIDENCE_CERT_PATH to load the TLS certificates from.
+// PROVIDENCE_TLS_VERIFY to enable or disable TLS verification, off by default.
+func FromEnv(c *Client) error {
+  if certPath := os.Getenv("PROVIDENCE_CERT_PATH"); certPath != "" {
+    options := tlsconfig.Options{
+      CAFile:             filepath.Join(certPath, "ca.pem"),
+      CertFile:           filepath.Join(certPath, "cert.pem"),
+      KeyFile:            filepath.Join(certPath, "key.pem"),
+      InsecureSkipVerify: os.Getenv("PROVIDENCE_TLS_VERIFY") == "",
+    }
+    tlsc, err := tlsconfig.Client(options)
+    if err != nil {
+      return err
+    }
+
+    c.client = &http.Client{
+      Transport: &http.Transport{
+        TLSClientConfig: tlsc,
+      },
+    }
+  }
+
+  host := os.Getenv("PROVIDENCE_HOST")
+  if host == "" {
+    host = c.host
+  }
+  if err := WithHost(host)(c); err != nil {
+    return err
+  }
+
+  if version := os.Getenv("PROVIDENCE_API_VERSION"); version != "" {
+    return WithVersion(version)(c)
+  }
+  return nil
+}
+
+// WithHost overrides the client host with the specified one. The transport
+// of the current HTTP client is configured to dial the host, so WithHost
+// must come after WithHTTPClient when a unix socket is used.
+func WithHost(host string) Opt {
+  return func(c *Client) error {
+    proto, addr, basePath, err := ParseHost(host)
+    if err != nil {
+      return err
+    }
+    c.host = host
+    c.proto = proto
+    c.addr = addr
+    c.basePath = basePath
+    if transport, ok := c.client.Transport.(*http.Transport); ok {
+      return sockets.ConfigureTransport(transport, c.proto, c.addr)
+    }
+    return nil
+  }
+}
+
+// WithVersion overrides the client version with the specified one. A
+// version set this way is never changed by API version negotiation.
+func WithVersion(version string) Opt {
+  return func(c *Client) error {
+    if version != "" {
+      c.version = version
+      c.manualOverride = true
+    }
+    return nil
+  }
+}
+
+// WithAPIVersionNegotiation negotiates the API version with the server
+// before the first request, so that the server is only pinged by the
+// commands which talk to it.
+func WithAPIVersionNegotiation() Opt {
+  return func(c *Client) error {
+    c.negotiateVersion = true
+    return nil
+  }
+}
+
+// WithHTTPClient overrides the client http client with the specified one.
+// A nil client keeps the default one.
+func WithHTTPClient(client *http.Client) Opt {
+  return func(c *Client) error {
+    if client != nil {
+      c.client = client
+    }
+    return nil
+  }
+}
+
+// WithHTTPHeaders overrides the client default http headers.
+func WithHTTPHeaders(headers map[string]string) Opt {
+  return func(c *Client) error {
+    c.customHTTPHeaders = headers
+    return nil
+  }
+}
+
+// WithTLSClientConfig applies a TLS config to the client transport, using
+// the given CA to verify the server and the certificate and key to
+// authenticate the client.
+func WithTLSClientConfig(cacertPath, certPath, keyPath string) Opt {
+  return func(c *Client) error {
+    config, err := tlsconfig.Client(tlsconfig.Options{
+      CAFile:   cacertPath,
+      CertFile: certPath,
+      KeyFile:  keyPath,
+    })
+    if err != nil {
+      return errors.Wrap(err, "failed to create tls config")
+    }
+    if transport, ok := c.client.Transport.(*http.Transport); ok {
+      transport.TLSClientConfig = config
+      return nil
+    }
+    return errors.Errorf("cannot apply tls config to transport: %T", c.client.Transport)
+  }
+}
+
+// WithTimeout configures the time limit for requests made by the HTTP
+// client, including reading the response body.
+func WithTimeout(timeout time.Duration) Opt {
+  return func(c *Client) error {
+    c.client.Timeout = timeout
+    return nil
+  }
+}
+
+// WithDialContext applies the dialer to the client transport. This can be
+// used to set the Timeout and KeepAlive settings of the client.
+func WithDialContext(dialContext func(ctx context.Context, network, addr string) (net.Conn, error)) Opt {
+  return func(c *Client) error {
+    if transport, ok := c.client.Transport.(*http.Transport); ok {
+      transport.DialContext = dialContext
+      return nil
+    }
+    return errors.Errorf("cannot apply dialer to transport: %T", c.client.Transport)
+  }
+}
+
+// WithRetryPolicy sets the policy used to retry requests that failed with a
+// transient error.
+func WithRetryPolicy(p RetryPolicy) Opt {
+  return func(c *Client) error {
+    c.retryPolicy = p
+    return nil
+  }
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+  "net/http"
+  "os"
+  "testing"
+  "time"
+)
+
+func TestNewClientWithOptsDefaults(t *testing.T) {
+  c, err := NewClientWithOpts()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.host != DefaultProvidenceHost || c.version != DefaultVersion || c.scheme != "http" {
+    t.Fatalf("unexpected defaults: host=%s version=%s scheme=%s", c.host, c.version, c.scheme)
+  }
+  if c.manualOverride {
+    t.Fatal("expected the default version not to be pinned")
+  }
+}
+
+func TestNewClientWithOpts(t *testing.T) {
+  httpClient := &http.Client{Transport: new(http.Transport)}
+  c, err := NewClientWithOpts(
+    WithHTTPClient(httpClient),
+    WithHost("unix:///var/run/providence.sock"),
+    WithVersion("1.0"),
+    WithHTTPHeaders(map[string]string{"X-Custom": "value"}),
+    WithTimeout(5*time.Second),
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.proto != "unix" || c.addr != "/var/run/providence.sock" {
+    t.Fatalf("unexpected host: proto=%s addr=%s", c.proto, c.addr)
+  }
+  if c.version != "1.0" || !c.manualOverride {
+    t.Fatalf("expected the version to be pinned to 1.0, got %s", c.version)
+  }
+  if c.client != httpClient || httpClient.Timeout != 5*time.Second {
+    t.Fatal("expected the given http client to be used with the timeout")
+  }
+  if c.customHTTPHeaders["X-Custom"] != "value" {
+    t.Fatalf("unexpected headers: %v", c.customHTTPHeaders)
+  }
+}
+
+func TestNewClient(t *testing.T) {
+  transport := new(http.Transport)
+  httpClient := &http.Client{Transport: transport}
+  c, err := NewClient("unix:///var/run/providence.sock", "1.1", httpClient, map[string]string{"X-Custom": "value"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.version != "1.1" || !c.manualOverride {
+    t.Fatalf("expected the version to be pinned to 1.1, got %s", c.version)
+  }
+  if c.client != httpClient || transport.DialContext == nil || !transport.DisableCompression {
+    t.Fatal("expected the given http client to be configured for the unix socket")
+  }
+  if c.customHTTPHeaders["X-Custom"] != "value" {
+    t.Fatalf("unexpected headers: %v", c.customHTTPHeaders)
+  }
+}
+
+func TestNewClientWithOptsInvalidHost(t *testing.T) {
+  if _, err := NewClientWithOpts(WithHost("localhost")); err == nil {
+    t.Fatal("expected an error for a host without a protocol")
+  }
+}
+
+func TestFromEnv(t *testing.T) {
+  defer os.Setenv("PROVIDENCE_HOST", os.Getenv("PROVIDENCE_HOST"))
+  defer os.Setenv("PROVIDENCE_API_VERSION", os.Getenv("PROVIDENCE_API_VERSION"))
+  defer os.Setenv("PROVIDENCE_CERT_PATH", os.Getenv("PROVIDENCE_CERT_PATH"))
+
+  os.Setenv("PROVIDENCE_HOST", "tcp://example.com:2376")
+  os.Setenv("PROVIDENCE_API_VERSION", "1.0")
+  os.Setenv("PROVIDENCE_CERT_PATH", "")
+
+  c, err := NewClientWithOpts(FromEnv, WithVersion("0.9"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.addr != "example.com:2376" {
+    t.Fatalf("expected the host from the environment, got %s", c.addr)
+  }
+  if c.version != "0.9" {
+    t.Fatalf("expected options after FromEnv to take precedence, got %s", c.version)
+  }
+
+  os.Setenv("PROVIDENCE_HOST", "")
+  os.Setenv("PROVIDENCE_API_VERSION", "")
+  c, err = NewEnvClient()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if c.host != DefaultProvidenceHost || c.version != DefaultVersion || c.manualOverride {
+    t.Fatalf("unexpected client: host=%s version=%s", c.host, c.version)
+  }
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// Ping pings the server and returns the API versions it advertises through
+// the "API-Version" and "Min-API-Version" headers. The versions are returned
+// even when the server rejects the request, so that they can be used to
+// negotiate a common API version.
+func (cli *Client) Ping(ctx context.Context) (types.Ping, error) {
+  serverResp, err := cli.head(ctx, "/_ping", nil, nil)
+  ping := parsePing(serverResp)
+  ensureReaderClosed(serverResp)
+  return ping, err
+}
+
+// parsePing returns the API versions advertised in the headers of the
+// response to a ping.
+func parsePing(serverResp serverResponse) types.Ping {
+  var ping types.Ping
+  if serverResp.header != nil {
+    ping.APIVersion = serverResp.header.Get("API-Version")
+    ping.MinAPIVersion = serverResp.header.Get("Min-API-Version")
+  }
+  return ping
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+func TestPingReadsVersionHeaders(t *testing.T) {
+  server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    if !strings.HasSuffix(r.URL.Path, "/_ping") || r.Method != "HEAD" {
+      t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+    }
+    w.Header().Set("API-Version", "1.0")
+    w.Header().Set("Min-API-Version", "0.9")
+    w.WriteHeader(http.StatusBadRequest)
+  }))
+  defer server.Close()
+
+  client, err := NewClientWithOpts(WithHost(server.URL))
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  ping, err := client.Ping(context.Background())
+  if err == nil {
+    t.Fatal("expected an error for a rejected ping")
+  }
+  if ping.APIVersion != "1.0" || ping.MinAPIVersion != "0.9" {
+    t.Fatalf("unexpected ping %+v", ping)
+  }
+
+  client.NegotiateAPIVersion(context.Background())
+  if client.ClientVersion() != "1.0" {
+    t.Fatalf("expected the client version to be downgraded to 1.0, got %s", client.ClientVersion())
+  }
+}
+
+func TestNegotiateAPIVersionPing(t *testing.T) {
+  cases := []struct {
+    clientVersion  string
+    manualOverride bool
+    ping           types.Ping
+    expected       string
+  }{
+    {"1.1", false, types.Ping{APIVersion: "1.0"}, "1.0"},
+    {"1.1", false, types.Ping{APIVersion: "1.2"}, "1.1"},
+    {"1.1", false, types.Ping{}, "1.1"},
+    {"", false, types.Ping{APIVersion: "1.0"}, "1.0"},
+    {"1.1", true, types.Ping{APIVersion: "1.0"}, "1.1"},
+  }
+
+  for _, c := range cases {
+    client := &Client{version: c.clientVersion, manualOverride: c.manualOverride}
+    client.NegotiateAPIVersionPing(c.ping)
+    if client.ClientVersion() != c.expected {
+      t.Fatalf("expected version %q for client %q and ping %+v, got %q", c.expected, c.clientVersion, c.ping, client.ClientVersion())
+    }
+  }
+}
+
+func TestAPIVersionNegotiationIsLazy(t *testing.T) {
+  var requests []string
+  server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    requests = append(requests, r.Method+" "+r.URL.Path)
+    w.Header().Set("API-Version", "1.0")
+    w.WriteHeader(http.StatusOK)
+  }))
+  defer server.Close()
+
+  client, err := NewClientWithOpts(WithHost(server.URL), WithAPIVersionNegotiation())
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(requests) != 0 {
+    t.Fatalf("expected no request before the first API call, got %v", requests)
+  }
+
+  for i := 0; i < 2; i++ {
+    resp, err := client.get(context.Background(), "/engines/json", nil, nil)
+    if err != nil {
+      t.Fatal(err)
+    }
+    ensureReaderClosed(resp)
+  }
+  expected := []string{"HEAD /v1.1/_ping", "GET /v1.0/engines/json", "GET /v1.0/engines/json"}
+  if strings.Join(requests, ", ") != strings.Join(expected, ", ") {
+    t.Fatalf("expected requests %v, got %v", expected, requests)
+  }
+}
@@ -11,7 +11,10 @@ import (
   "net/url"
   "os"
   "strings"
+  "time"
 
+  "github.com/Sirupsen/logrus"
+  "github.com/TopPano/providence-cli/api/errdefs"
   "github.com/pkg/errors"
   "golang.org/x/net/context"
   "golang.org/x/net/context/ctxhttp"
@@ -102,6 +105,9 @@ func (cli *Client) buildRequest(method, path string, body io.Reader, headers hea
   }
   req = cli.addHeaders(req, headers)
 
+  if cli.proto == "unix" {
+    req.Host = DummyHost
+  }
   req.URL.Host = cli.addr
   req.URL.Scheme = cli.scheme
 
@@ -112,11 +118,34 @@ func (cli *Client) buildRequest(method, path string, body io.Reader, headers hea
 }
 
 func (cli *Client) sendRequest(ctx context.Context, method, path string, query url.Values, body io.Reader, headers headers) (serverResponse, error) {
+  if cli.negotiateVersion && !cli.negotiated {
+    cli.NegotiateAPIVersion(ctx)
+  }
+
   req, err := cli.buildRequest(method, cli.getAPIPath(path, query), body, headers)
   if err != nil {
     return serverResponse{}, err
   }
-  return cli.doRequest(ctx, req)
+
+  resp, err := cli.doRequest(ctx, req)
+  for attempt := 1; err != nil && attempt <= cli.retryPolicy.MaxRetries && isRetryable(req, err); attempt++ {
+    delay := cli.retryPolicy.backoff(attempt, resp.header)
+    logrus.Debugf("%s %s failed, retrying in %s (%d/%d): %v", method, req.URL.Path, delay, attempt, cli.retryPolicy.MaxRetries, err)
+
+    select {
+    case <-ctx.Done():
+      return resp, err
+    case <-time.After(delay):
+    }
+
+    if req.GetBody != nil {
+      if req.Body, err = req.GetBody(); err != nil {
+        return serverResponse{}, err
+      }
+    }
+    resp, err = cli.doRequest(ctx, req)
+  }
+  return resp, err
 }
 
 func (cli *Client) doRequest(ctx context.Context, req *http.Request) (serverResponse, error) {
@@ -142,7 +171,10 @@ func (cli *Client) doRequest(ctx context.Context, req *http.Request) (serverResp
     if nErr, ok := err.(*url.Error); ok {
       if nErr, ok := nErr.Err.(*net.OpError); ok {
         if os.IsPermission(nErr.Err) {
-          return serverResp, errors.Wrapf(err, "Got permission denied while trying to connect to the Providence  socket at %v", cli.host)
+          if cli.proto == "unix" {
+            return serverResp, errors.Wrapf(err, "Got permission denied while trying to connect to the Providence server socket at %v", cli.addr)
+          }
+          return serverResp, errors.Wrapf(err, "Got permission denied while trying to connect to the Providence server at %v", cli.host)
         }
       }
     }
@@ -158,41 +190,53 @@ func (cli *Client) doRequest(ctx context.Context, req *http.Request) (serverResp
       }
     }
 
-    return serverResp, errors.Wrap(err, "error during connect")
+    return serverResp, errdefs.Unavailable(errors.Wrap(err, "error during connect"))
   }
 
   if resp != nil {
     serverResp.statusCode = resp.StatusCode
+    serverResp.header = resp.Header
   }
 
   if serverResp.statusCode < 200 || serverResp.statusCode >= 400 {
-    body, err := ioutil.ReadAll(resp.Body)
-    if err != nil {
-      return serverResp, err
-    }
-    if len(body) == 0 {
-      return serverResp, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(serverResp.statusCode), req.URL)
-    }
-
-    var errorMessage string
-    if resp.Header.Get("Content-Type") == "application/json" {
-      var errorResponse ErrorResponse
-      if err := json.Unmarshal(body, &errorResponse); err != nil {
-        return serverResp, fmt.Errorf("Error reading JSON: %v", err)
-      }
-      errorMessage = errorResponse.Message
-    } else {
-      errorMessage = string(body)
-    }
-
-    return serverResp, fmt.Errorf("Error response from server: %s", strings.TrimSpace(errorMessage))
+    return serverResp, errdefs.FromStatusCode(cli.checkResponseErr(resp, req), serverResp.statusCode)
   }
 
   serverResp.body = resp.Body
-  serverResp.header = resp.Header
   return serverResp, nil
 }
 
+// checkResponseErr reads the body of an unsuccessful response and turns it
+// into an error carrying the message and the request ID sent by the server.
+func (cli *Client) checkResponseErr(resp *http.Response, req *http.Request) error {
+  defer resp.Body.Close()
+
+  body, err := ioutil.ReadAll(resp.Body)
+  if err != nil {
+    return err
+  }
+
+  serverErr := serverError{
+    statusCode: resp.StatusCode,
+    requestID:  resp.Header.Get("X-Request-Id"),
+  }
+  if len(body) == 0 {
+    serverErr.message = fmt.Sprintf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(resp.StatusCode), req.URL)
+    return serverErr
+  }
+
+  if resp.Header.Get("Content-Type") == "application/json" {
+    var errorResponse ErrorResponse
+    if err := json.Unmarshal(body, &errorResponse); err != nil {
+      return fmt.Errorf("Error reading JSON: %v", err)
+    }
+    serverErr.message = "Error response from server: " + strings.TrimSpace(errorResponse.Message)
+  } else {
+    serverErr.message = "Error response from server: " + strings.TrimSpace(string(body))
+  }
+  return serverErr
+}
+
 func (cli *Client) addHeaders(req *http.Request, headers headers) *http.Request {
   // Add CLI Config's HTTP Headers BEFORE we set the providence headers
   // then the user can't change OUR headers
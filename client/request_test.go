@@ -0,0 +1,54 @@
+package client
+
+import (
+  "net/http"
+  "net/http/httptest"
+  "testing"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+func TestServerErrorsAreClassified(t *testing.T) {
+  cases := []struct {
+    statusCode int
+    check      func(error) bool
+  }{
+    {http.StatusBadRequest, errdefs.IsInvalidParameter},
+    {http.StatusUnauthorized, errdefs.IsUnauthorized},
+    {http.StatusForbidden, errdefs.IsForbidden},
+    {http.StatusNotFound, errdefs.IsNotFound},
+    {http.StatusConflict, errdefs.IsConflict},
+    {http.StatusServiceUnavailable, errdefs.IsUnavailable},
+    {http.StatusInternalServerError, errdefs.IsSystem},
+  }
+
+  for _, c := range cases {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+      w.Header().Set("Content-Type", "application/json")
+      w.Header().Set("X-Request-Id", "abc123")
+      w.WriteHeader(c.statusCode)
+      w.Write([]byte(`{"message":"something went wrong"}`))
+    }))
+
+    client, err := NewClient(server.URL, "1.1", nil, nil)
+    if err != nil {
+      t.Fatal(err)
+    }
+    _, err = client.EngineList(context.Background(), types.EngineListOptions{})
+    server.Close()
+
+    if !c.check(err) {
+      t.Fatalf("unexpected class for status code %d: %v", c.statusCode, err)
+    }
+    expected := "Error response from server: something went wrong (request ID: abc123)"
+    if err.Error() != expected {
+      t.Fatalf("expected %q, got %q", expected, err.Error())
+    }
+    if RequestID(err) != "abc123" {
+      t.Fatalf("expected request ID abc123, got %q", RequestID(err))
+    }
+  }
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+  "math/rand"
+  "net/http"
+  "strconv"
+  "time"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+)
+
+// RetryPolicy configures how requests that failed with a transient error,
+// such as a connection reset or a 502/503 response, are retried.
+type RetryPolicy struct {
+  // MaxRetries is the number of times a request is retried after the
+  // first attempt. Zero disables retries.
+  MaxRetries int
+  // MinBackoff is the delay before the first retry. It doubles on each
+  // following retry.
+  MinBackoff time.Duration
+  // MaxBackoff caps the delay between two attempts.
+  MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of new clients. It doesn't retry
+// requests; set MaxRetries to enable retries.
+var DefaultRetryPolicy = RetryPolicy{
+  MaxRetries: 0,
+  MinBackoff: 500 * time.Millisecond,
+  MaxBackoff: 10 * time.Second,
+}
+
+// SetRetryPolicy sets the policy used to retry requests that failed with a
+// transient error.
+func (cli *Client) SetRetryPolicy(p RetryPolicy) {
+  cli.retryPolicy = p
+}
+
+// backoff returns the delay to wait before the given retry attempt, starting
+// at 1. A Retry-After header sent by the server takes precedence over the
+// exponential backoff, but is capped by MaxBackoff too.
+func (p RetryPolicy) backoff(attempt int, header http.Header) time.Duration {
+  if d, ok := retryAfter(header); ok {
+    if p.MaxBackoff > 0 && d > p.MaxBackoff {
+      d = p.MaxBackoff
+    }
+    return d
+  }
+
+  d := p.MinBackoff
+  for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+    d *= 2
+  }
+  if p.MaxBackoff > 0 && d > p.MaxBackoff {
+    d = p.MaxBackoff
+  }
+  if d <= 0 {
+    return 0
+  }
+  // Add jitter so that clients failing at the same time don't retry
+  // in lockstep.
+  return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as
+// an HTTP date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+  value := header.Get("Retry-After")
+  if value == "" {
+    return 0, false
+  }
+  if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+    return time.Duration(seconds) * time.Second, true
+  }
+  if t, err := http.ParseTime(value); err == nil {
+    d := t.Sub(time.Now())
+    if d < 0 {
+      d = 0
+    }
+    return d, true
+  }
+  return 0, false
+}
+
+// isRetryable returns true if req failed with a transient error and can be
+// sent again safely: its method must be idempotent, or POST, and its body
+// must be replayable.
+func isRetryable(req *http.Request, err error) bool {
+  if !errdefs.IsUnavailable(err) {
+    return false
+  }
+
+  switch req.Method {
+  case "GET", "HEAD", "DELETE", "PUT", "POST":
+  default:
+    return false
+  }
+  return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
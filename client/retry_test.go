@@ -0,0 +1,108 @@
+package client
+
+import (
+  "io/ioutil"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+  "time"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+func newFlakyServer(failures int, attempts *int) *httptest.Server {
+  return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    *attempts++
+    ioutil.ReadAll(r.Body)
+    if *attempts <= failures {
+      w.WriteHeader(http.StatusServiceUnavailable)
+      w.Write([]byte("try again later"))
+      return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.Write([]byte("[]"))
+  }))
+}
+
+func newRetryingClient(t *testing.T, host string, maxRetries int) *Client {
+  client, err := NewClient(host, "1.1", nil, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  client.SetRetryPolicy(RetryPolicy{MaxRetries: maxRetries, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+  return client
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+  attempts := 0
+  server := newFlakyServer(2, &attempts)
+  defer server.Close()
+
+  client := newRetryingClient(t, server.URL, 3)
+  if _, err := client.EngineList(context.Background(), types.EngineListOptions{}); err != nil {
+    t.Fatal(err)
+  }
+  if attempts != 3 {
+    t.Fatalf("expected 3 attempts, got %d", attempts)
+  }
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+  attempts := 0
+  server := newFlakyServer(5, &attempts)
+  defer server.Close()
+
+  client := newRetryingClient(t, server.URL, 2)
+  _, err := client.EngineList(context.Background(), types.EngineListOptions{})
+  if !errdefs.IsUnavailable(err) {
+    t.Fatalf("expected an unavailable error, got %v", err)
+  }
+  if attempts != 3 {
+    t.Fatalf("expected 3 attempts, got %d", attempts)
+  }
+}
+
+func TestRetrySkipsNonReplayableBody(t *testing.T) {
+  attempts := 0
+  server := newFlakyServer(1, &attempts)
+  defer server.Close()
+
+  client := newRetryingClient(t, server.URL, 3)
+  buildContext := ioutil.NopCloser(strings.NewReader("context"))
+  if _, err := client.EngineBuild(context.Background(), buildContext, types.EngineBuildOptions{}); err == nil {
+    t.Fatal("expected the build to fail")
+  }
+  if attempts != 1 {
+    t.Fatalf("expected a single attempt, got %d", attempts)
+  }
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+  header := http.Header{}
+  header.Set("Retry-After", "3")
+  p := RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Second}
+  if d := p.backoff(1, header); d != 3*time.Second {
+    t.Fatalf("expected the Retry-After delay to be honoured, got %s", d)
+  }
+
+  header.Set("Retry-After", "86400")
+  if d := p.backoff(1, header); d != 10*time.Second {
+    t.Fatalf("expected the Retry-After delay to be capped, got %s", d)
+  }
+  header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+  if d := p.backoff(1, header); d != 10*time.Second {
+    t.Fatalf("expected the Retry-After date to be capped, got %s", d)
+  }
+  if d := (RetryPolicy{}).backoff(1, header); d < 59*time.Minute {
+    t.Fatalf("expected the Retry-After date to be honoured without MaxBackoff, got %s", d)
+  }
+
+  p.MaxBackoff = time.Second
+  if d := p.backoff(20, nil); d > time.Second || d < time.Second/2 {
+    t.Fatalf("expected the backoff to be capped, got %s", d)
+  }
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+  "encoding/json"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// ServerVersion returns information of the Providence server host.
+func (cli *Client) ServerVersion(ctx context.Context) (types.Version, error) {
+  resp, err := cli.get(ctx, "/version", nil, nil)
+  if err != nil {
+    return types.Version{}, err
+  }
+
+  var server types.Version
+  err = json.NewDecoder(resp.body).Decode(&server)
+  ensureReaderClosed(resp)
+  return server, err
+}
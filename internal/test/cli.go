@@ -0,0 +1,85 @@
+// Package test provides helpers to test the client and the commands
+// without a Providence server.
+package test
+
+import (
+  "bytes"
+  "io"
+  "io/ioutil"
+  "strings"
+
+  "github.com/TopPano/providence-cli/cli/command"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
+  "github.com/TopPano/providence-cli/client"
+  "github.com/dnephin/cobra"
+)
+
+// FakeCli is a ProvCli whose input is given by the test and whose output
+// and error streams are captured.
+type FakeCli struct {
+  *command.ProvCli
+  outBuffer *bytes.Buffer
+  errBuffer *bytes.Buffer
+}
+
+// FakeCliOption configures a FakeCli.
+type FakeCliOption func(*fakeCliConfig)
+
+type fakeCliConfig struct {
+  in         io.Reader
+  configFile *cliconfig.ConfigFile
+}
+
+// WithStdin sets the input stream of the FakeCli.
+func WithStdin(in io.Reader) FakeCliOption {
+  return func(c *fakeCliConfig) {
+    c.in = in
+  }
+}
+
+// WithConfigFile sets the configuration of the FakeCli.
+func WithConfigFile(configFile *cliconfig.ConfigFile) FakeCliOption {
+  return func(c *fakeCliConfig) {
+    c.configFile = configFile
+  }
+}
+
+// NewFakeCli returns a FakeCli using the given APIClient, which may be a
+// FakeClient or a client connected to a fake server.
+func NewFakeCli(apiClient client.APIClient, opts ...FakeCliOption) *FakeCli {
+  config := fakeCliConfig{
+    in:         strings.NewReader(""),
+    configFile: cliconfig.NewConfigFile(""),
+  }
+  for _, opt := range opts {
+    opt(&config)
+  }
+
+  outBuffer := new(bytes.Buffer)
+  errBuffer := new(bytes.Buffer)
+  return &FakeCli{
+    ProvCli:   command.NewProvCliWithClient(ioutil.NopCloser(config.in), outBuffer, errBuffer, apiClient, config.configFile),
+    outBuffer: outBuffer,
+    errBuffer: errBuffer,
+  }
+}
+
+// RunCommand executes cmd with the given arguments, as the prov binary
+// would do, and returns the error of the command.
+func (c *FakeCli) RunCommand(cmd *cobra.Command, args ...string) error {
+  cmd.SetArgs(args)
+  cmd.SetOutput(c.errBuffer)
+  cmd.SilenceUsage = true
+  cmd.SilenceErrors = true
+  return cmd.Execute()
+}
+
+// OutBuffer returns what the commands wrote to the output stream.
+func (c *FakeCli) OutBuffer() *bytes.Buffer {
+  return c.outBuffer
+}
+
+// ErrBuffer returns what the commands wrote to the error stream.
+func (c *FakeCli) ErrBuffer() *bytes.Buffer {
+  return c.errBuffer
+}
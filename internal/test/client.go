@@ -0,0 +1,138 @@
+package test
+
+import (
+  "errors"
+  "io"
+  "io/ioutil"
+  "strings"
+
+  "golang.org/x/net/context"
+
+  "github.com/TopPano/providence-cli/api/errdefs"
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/api/types/versions"
+  "github.com/TopPano/providence-cli/client"
+)
+
+// FakeClient is a programmable client.APIClient. Each method calls the
+// function of the same name when it is set and returns zero values
+// otherwise.
+type FakeClient struct {
+  // Version is the API version reported by ClientVersion. It defaults to
+  // client.DefaultVersion.
+  Version string
+
+  ContextCachedFunc        func(ctx context.Context, digest string) (bool, error)
+  ContextSessionFunc       func(ctx context.Context, request types.ContextSessionRequest) (types.ContextSessionResponse, error)
+  EngineBuildFunc          func(ctx context.Context, buildContext io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error)
+  EngineInspectWithRawFunc func(ctx context.Context, engineID string) (types.EngineInspect, []byte, error)
+  EngineListFunc           func(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error)
+  EngineRemoveFunc         func(ctx context.Context, engineID string, options types.EngineRemoveOptions) ([]types.EngineDeleteResponseItem, error)
+  EngineTagFunc            func(ctx context.Context, source, target string) error
+  PingFunc                 func(ctx context.Context) (types.Ping, error)
+  ServerVersionFunc        func(ctx context.Context) (types.Version, error)
+}
+
+// Ensure that FakeClient always implements APIClient.
+var _ client.APIClient = &FakeClient{}
+
+// ContextCached calls ContextCachedFunc. By default no build context is
+// cached.
+func (c *FakeClient) ContextCached(ctx context.Context, digest string) (bool, error) {
+  if c.ContextCachedFunc != nil {
+    return c.ContextCachedFunc(ctx, digest)
+  }
+  return false, nil
+}
+
+// ContextSession calls ContextSessionFunc. By default the server doesn't
+// support context sessions.
+func (c *FakeClient) ContextSession(ctx context.Context, request types.ContextSessionRequest) (types.ContextSessionResponse, error) {
+  if c.ContextSessionFunc != nil {
+    return c.ContextSessionFunc(ctx, request)
+  }
+  return types.ContextSessionResponse{}, errdefs.NotFound(errors.New("page not found"))
+}
+
+// EngineBuild calls EngineBuildFunc. By default the build context is
+// drained and an empty build output is returned.
+func (c *FakeClient) EngineBuild(ctx context.Context, buildContext io.Reader, options types.EngineBuildOptions) (types.EngineBuildResponse, error) {
+  if c.EngineBuildFunc != nil {
+    return c.EngineBuildFunc(ctx, buildContext, options)
+  }
+  if buildContext != nil {
+    io.Copy(ioutil.Discard, buildContext)
+  }
+  return types.EngineBuildResponse{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+// EngineInspectWithRaw calls EngineInspectWithRawFunc.
+func (c *FakeClient) EngineInspectWithRaw(ctx context.Context, engineID string) (types.EngineInspect, []byte, error) {
+  if c.EngineInspectWithRawFunc != nil {
+    return c.EngineInspectWithRawFunc(ctx, engineID)
+  }
+  return types.EngineInspect{}, nil, nil
+}
+
+// EngineList calls EngineListFunc.
+func (c *FakeClient) EngineList(ctx context.Context, options types.EngineListOptions) ([]types.EngineSummary, error) {
+  if c.EngineListFunc != nil {
+    return c.EngineListFunc(ctx, options)
+  }
+  return []types.EngineSummary{}, nil
+}
+
+// EngineRemove calls EngineRemoveFunc.
+func (c *FakeClient) EngineRemove(ctx context.Context, engineID string, options types.EngineRemoveOptions) ([]types.EngineDeleteResponseItem, error) {
+  if c.EngineRemoveFunc != nil {
+    return c.EngineRemoveFunc(ctx, engineID, options)
+  }
+  return []types.EngineDeleteResponseItem{}, nil
+}
+
+// EngineTag calls EngineTagFunc.
+func (c *FakeClient) EngineTag(ctx context.Context, source, target string) error {
+  if c.EngineTagFunc != nil {
+    return c.EngineTagFunc(ctx, source, target)
+  }
+  return nil
+}
+
+// Ping calls PingFunc.
+func (c *FakeClient) Ping(ctx context.Context) (types.Ping, error) {
+  if c.PingFunc != nil {
+    return c.PingFunc(ctx)
+  }
+  return types.Ping{}, nil
+}
+
+// ServerVersion calls ServerVersionFunc.
+func (c *FakeClient) ServerVersion(ctx context.Context) (types.Version, error) {
+  if c.ServerVersionFunc != nil {
+    return c.ServerVersionFunc(ctx)
+  }
+  return types.Version{}, nil
+}
+
+// ClientVersion returns the API version of the client.
+func (c *FakeClient) ClientVersion() string {
+  if c.Version == "" {
+    return client.DefaultVersion
+  }
+  return c.Version
+}
+
+// NegotiateAPIVersion pings the fake server and downgrades the client
+// version if needed.
+func (c *FakeClient) NegotiateAPIVersion(ctx context.Context) {
+  ping, _ := c.Ping(ctx)
+  c.NegotiateAPIVersionPing(ping)
+}
+
+// NegotiateAPIVersionPing downgrades the client version to the one of the
+// ping if it is older.
+func (c *FakeClient) NegotiateAPIVersionPing(p types.Ping) {
+  if p.APIVersion != "" && versions.LessThan(p.APIVersion, c.ClientVersion()) {
+    c.Version = p.APIVersion
+  }
+}
@@ -0,0 +1,91 @@
+package fakeserver
+
+import (
+  "archive/tar"
+  "bufio"
+  "bytes"
+  "compress/gzip"
+  "crypto/sha256"
+  "encoding/hex"
+  "io"
+  "io/ioutil"
+  "net/http"
+  "strings"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// contextCached reports whether a build context with the given digest was
+// uploaded.
+func (s *Server) contextCached(w http.ResponseWriter, digest string) {
+  s.mu.Lock()
+  _, ok := s.contexts[digest]
+  s.mu.Unlock()
+  if !ok {
+    w.WriteHeader(http.StatusNotFound)
+    return
+  }
+  w.WriteHeader(http.StatusOK)
+}
+
+// storeContext reads an uploaded build context and returns the ID of the
+// engine built from it. The build context is cached under the digest of the
+// manifest of its entries, unless it isn't a tar stream.
+func (s *Server) storeContext(body io.Reader) (string, error) {
+  content, err := ioutil.ReadAll(body)
+  if err != nil {
+    return "", err
+  }
+  sum := sha256.Sum256(content)
+  id := "sha256:" + hex.EncodeToString(sum[:])
+
+  if digest, err := contextDigest(content); err == nil {
+    s.mu.Lock()
+    s.contexts[digest] = id
+    s.mu.Unlock()
+  }
+  return id, nil
+}
+
+// contextDigest returns the digest of the manifest of the entries of a
+// build context.
+func contextDigest(content []byte) (string, error) {
+  r, err := decompress(bytes.NewReader(content))
+  if err != nil {
+    return "", err
+  }
+  manifest := types.ContextManifest{Entries: []types.ContextManifestEntry{}}
+  tr := tar.NewReader(r)
+  for {
+    hdr, err := tr.Next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return "", err
+    }
+    entry := types.ContextManifestEntry{
+      Path:     strings.TrimSuffix(hdr.Name, "/"),
+      Mode:     hdr.FileInfo().Mode(),
+      Linkname: hdr.Linkname,
+    }
+    if hdr.Typeflag == tar.TypeReg {
+      h := sha256.New()
+      if entry.Size, err = io.Copy(h, tr); err != nil {
+        return "", err
+      }
+      entry.Hash = "sha256:" + hex.EncodeToString(h.Sum(nil))
+    }
+    manifest.Entries = append(manifest.Entries, entry)
+  }
+  return manifest.Digest()
+}
+
+// decompress returns the content of r, which may be gzipped.
+func decompress(r io.Reader) (io.Reader, error) {
+  buf := bufio.NewReader(r)
+  if magic, err := buf.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+    return gzip.NewReader(buf)
+  }
+  return buf, nil
+}
@@ -0,0 +1,468 @@
+// Package fakeserver provides an in-process fake Providence server built on
+// net/http/httptest. It keeps a set of engines in memory, streams build
+// output as JSON messages, and can inject errors and latency so that the
+// client and the commands can be tested without a real server.
+package fakeserver
+
+import (
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "net/http/httptest"
+  "regexp"
+  "sort"
+  "strings"
+  "sync"
+  "time"
+
+  "github.com/TopPano/providence-cli/api/types"
+  "github.com/TopPano/providence-cli/api/types/filters"
+  "github.com/docker/docker/pkg/jsonmessage"
+)
+
+// Default versions advertised by the server.
+const (
+  DefaultAPIVersion    = "1.1"
+  DefaultMinAPIVersion = "1.0"
+)
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+// InjectedError describes an error response returned instead of the
+// regular one.
+type InjectedError struct {
+  // StatusCode is the HTTP status code of the response.
+  StatusCode int
+  // Message is sent as the JSON error message.
+  Message string
+  // Times is the number of requests that fail. Zero means every request.
+  Times int
+}
+
+// Server is a fake Providence server. Its exported fields may be changed
+// between requests to alter its behavior.
+type Server struct {
+  *httptest.Server
+
+  // APIVersion and MinAPIVersion are advertised by the ping endpoint.
+  APIVersion    string
+  MinAPIVersion string
+  // Version is returned by the version endpoint.
+  Version types.Version
+  // Latency delays every response.
+  Latency time.Duration
+  // BuildSteps are the Enginefile instructions reported by builds.
+  BuildSteps []string
+  // CachedSteps is the number of leading build steps reported as
+  // using the build cache.
+  CachedSteps int
+  // BuildError makes builds fail with the given message once all the
+  // steps have been reported.
+  BuildError string
+
+  mu           sync.Mutex
+  engines      []*types.EngineInspect
+  errors       map[string]*InjectedError
+  requests     []string
+  blobs        map[string][]byte
+  contexts     map[string]string
+  sessions     map[string]bool
+  sessionCount int
+}
+
+// New starts a fake Providence server. It must be closed by the caller.
+func New() *Server {
+  s := &Server{
+    APIVersion:    DefaultAPIVersion,
+    MinAPIVersion: DefaultMinAPIVersion,
+    Version: types.Version{
+      Version:       "fake",
+      APIVersion:    DefaultAPIVersion,
+      MinAPIVersion: DefaultMinAPIVersion,
+      GitCommit:     "fake-commit",
+      GoVersion:     "go",
+      Os:            "linux",
+      Arch:          "amd64",
+    },
+    BuildSteps: []string{"FROM scratch"},
+    errors:     map[string]*InjectedError{},
+    blobs:      map[string][]byte{},
+    contexts:   map[string]string{},
+    sessions:   map[string]bool{},
+  }
+  s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+  return s
+}
+
+// Host returns the address of the server in the form expected by the client.
+func (s *Server) Host() string {
+  return "tcp://" + s.Listener.Addr().String()
+}
+
+// AddEngine adds an engine to the server.
+func (s *Server) AddEngine(engine types.EngineInspect) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.engines = append(s.engines, &engine)
+}
+
+// Engines returns the engines held by the server.
+func (s *Server) Engines() []types.EngineInspect {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  engines := make([]types.EngineInspect, 0, len(s.engines))
+  for _, e := range s.engines {
+    engines = append(engines, *e)
+  }
+  return engines
+}
+
+// InjectError makes requests matching method and path, without the API
+// version prefix, fail with the given error.
+func (s *Server) InjectError(method, path string, e InjectedError) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.errors[method+" "+path] = &e
+}
+
+// ClearErrors removes all the injected errors.
+func (s *Server) ClearErrors() {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.errors = map[string]*InjectedError{}
+}
+
+// Requests returns the requests received by the server so far, as
+// "METHOD path" strings with the API version prefix.
+func (s *Server) Requests() []string {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+  s.mu.Lock()
+  s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+  s.mu.Unlock()
+
+  if s.Latency > 0 {
+    time.Sleep(s.Latency)
+  }
+
+  w.Header().Set("API-Version", s.APIVersion)
+  w.Header().Set("Min-API-Version", s.MinAPIVersion)
+
+  path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+  if e := s.takeError(r.Method, path); e != nil {
+    writeError(w, e.StatusCode, e.Message)
+    return
+  }
+
+  // Engine names may contain slashes, so the name is whatever sits
+  // between the route prefix and suffix.
+  name := strings.TrimPrefix(path, "/engine/")
+  switch {
+  case path == "/_ping":
+    w.Write([]byte("OK"))
+  case path == "/version" && r.Method == "GET":
+    writeJSON(w, s.Version)
+  case path == "/engine" && r.Method == "POST":
+    s.build(w, r)
+  case path == "/context/session" && r.Method == "POST":
+    s.contextSession(w, r)
+  case strings.HasPrefix(path, "/context/sha256:") && r.Method == "HEAD":
+    s.contextCached(w, strings.TrimPrefix(path, "/context/"))
+  case path == "/engine/json" && r.Method == "GET":
+    s.list(w, r)
+  case name != path && strings.HasSuffix(name, "/json") && r.Method == "GET":
+    s.inspect(w, strings.TrimSuffix(name, "/json"))
+  case name != path && strings.HasSuffix(name, "/tag") && r.Method == "POST":
+    s.tag(w, r, strings.TrimSuffix(name, "/tag"))
+  case name != path && r.Method == "DELETE":
+    s.remove(w, r, name)
+  default:
+    writeError(w, http.StatusNotFound, fmt.Sprintf("page not found: %s %s", r.Method, path))
+  }
+}
+
+func (s *Server) takeError(method, path string) *InjectedError {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  key := method + " " + path
+  e, ok := s.errors[key]
+  if !ok {
+    return nil
+  }
+  if e.Times > 0 {
+    e.Times--
+    if e.Times == 0 {
+      delete(s.errors, key)
+    }
+  }
+  return e
+}
+
+// find returns the engine referenced by ID, ID prefix or name.
+func (s *Server) find(ref string) *types.EngineInspect {
+  name := normalizeName(ref)
+  for _, e := range s.engines {
+    for _, tag := range e.RepoTags {
+      if tag == name {
+        return e
+      }
+    }
+  }
+  for _, e := range s.engines {
+    id := strings.TrimPrefix(e.ID, "sha256:")
+    if e.ID == ref || strings.HasPrefix(id, strings.TrimPrefix(ref, "sha256:")) {
+      return e
+    }
+  }
+  return nil
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+  args, err := filters.FromParam(r.URL.Query().Get("filters"))
+  if err != nil {
+    writeError(w, http.StatusBadRequest, err.Error())
+    return
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  summaries := []types.EngineSummary{}
+  for _, e := range s.engines {
+    if args.Include("dangling") && args.ExactMatch("dangling", "true") != (len(e.RepoTags) == 0) {
+      continue
+    }
+    if !matchLabels(e.Labels, args.Get("label")) {
+      continue
+    }
+    created, _ := time.Parse(time.RFC3339Nano, e.Created)
+    summaries = append(summaries, types.EngineSummary{
+      ID:       e.ID,
+      ParentID: e.ParentID,
+      RepoTags: e.RepoTags,
+      Created:  created.Unix(),
+      Size:     e.Size,
+      Labels:   e.Labels,
+    })
+  }
+  writeJSON(w, summaries)
+}
+
+func matchLabels(labels map[string]string, wanted []string) bool {
+  for _, label := range wanted {
+    kv := strings.SplitN(label, "=", 2)
+    value, ok := labels[kv[0]]
+    if !ok || (len(kv) == 2 && value != kv[1]) {
+      return false
+    }
+  }
+  return true
+}
+
+func (s *Server) inspect(w http.ResponseWriter, ref string) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  e := s.find(ref)
+  if e == nil {
+    writeError(w, http.StatusNotFound, "No such engine: "+ref)
+    return
+  }
+  writeJSON(w, e)
+}
+
+func (s *Server) tag(w http.ResponseWriter, r *http.Request, ref string) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  e := s.find(ref)
+  if e == nil {
+    writeError(w, http.StatusNotFound, "No such engine: "+ref)
+    return
+  }
+  name := r.URL.Query().Get("repo") + ":" + r.URL.Query().Get("tag")
+  s.untag(name)
+  e.RepoTags = append(e.RepoTags, name)
+  w.WriteHeader(http.StatusCreated)
+}
+
+// untag removes name from the engine it currently references, if any.
+func (s *Server) untag(name string) bool {
+  for _, e := range s.engines {
+    for i, tag := range e.RepoTags {
+      if tag == name {
+        e.RepoTags = append(e.RepoTags[:i], e.RepoTags[i+1:]...)
+        return true
+      }
+    }
+  }
+  return false
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request, ref string) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  e := s.find(ref)
+  if e == nil {
+    writeError(w, http.StatusNotFound, "No such engine: "+ref)
+    return
+  }
+
+  byID := strings.HasPrefix(strings.TrimPrefix(e.ID, "sha256:"), strings.TrimPrefix(ref, "sha256:"))
+  if !byID && len(e.RepoTags) > 1 {
+    name := normalizeName(ref)
+    s.untag(name)
+    writeJSON(w, []types.EngineDeleteResponseItem{{Untagged: name}})
+    return
+  }
+  if byID && len(e.RepoTags) > 1 && r.URL.Query().Get("force") == "" {
+    writeError(w, http.StatusConflict, fmt.Sprintf("conflict: unable to delete %s (must be forced) - engine is referenced in multiple repositories", ref))
+    return
+  }
+
+  var items []types.EngineDeleteResponseItem
+  for _, tag := range e.RepoTags {
+    items = append(items, types.EngineDeleteResponseItem{Untagged: tag})
+  }
+  items = append(items, types.EngineDeleteResponseItem{Deleted: e.ID})
+  for i := range s.engines {
+    if s.engines[i] == e {
+      s.engines = append(s.engines[:i], s.engines[i+1:]...)
+      break
+    }
+  }
+  writeJSON(w, items)
+}
+
+func (s *Server) build(w http.ResponseWriter, r *http.Request) {
+  query := r.URL.Query()
+
+  // Builds of a context session are identified by their manifest, other
+  // builds by their build context
+  var id string
+  if digest := query.Get("context"); digest != "" {
+    s.mu.Lock()
+    id = s.contexts[digest]
+    s.mu.Unlock()
+    if id == "" {
+      writeError(w, http.StatusNotFound, fmt.Sprintf("build context %s not found", digest))
+      return
+    }
+  } else if session := query.Get("session"); session != "" {
+    manifest, err := s.readSessionContext(session, r.Body)
+    if err != nil {
+      writeError(w, http.StatusBadRequest, err.Error())
+      return
+    }
+    sum := sha256.Sum256(manifest)
+    id = "sha256:" + hex.EncodeToString(sum[:])
+  } else {
+    var err error
+    if id, err = s.storeContext(r.Body); err != nil {
+      writeError(w, http.StatusBadRequest, err.Error())
+      return
+    }
+  }
+
+  var buildArgs map[string]*string
+  if b := query.Get("buildargs"); b != "" {
+    if err := json.Unmarshal([]byte(b), &buildArgs); err != nil {
+      writeError(w, http.StatusBadRequest, err.Error())
+      return
+    }
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(http.StatusOK)
+  enc := json.NewEncoder(w)
+  flush := func() {
+    if f, ok := w.(http.Flusher); ok {
+      f.Flush()
+    }
+  }
+
+  for i, step := range s.BuildSteps {
+    enc.Encode(jsonmessage.JSONMessage{Stream: fmt.Sprintf("Step %d/%d : %s\n", i+1, len(s.BuildSteps), step)})
+    if i < s.CachedSteps {
+      enc.Encode(jsonmessage.JSONMessage{Stream: " ---> Using cache\n"})
+    }
+    flush()
+  }
+  if s.BuildError != "" {
+    enc.Encode(jsonmessage.JSONMessage{
+      Error:        &jsonmessage.JSONError{Message: s.BuildError},
+      ErrorMessage: s.BuildError,
+    })
+    return
+  }
+
+  var unused []string
+  for name := range buildArgs {
+    if !s.usesBuildArg(name) {
+      unused = append(unused, name)
+    }
+  }
+  sort.Strings(unused)
+  aux, _ := json.Marshal(types.BuildResult{ID: id, UnusedBuildArgs: unused})
+  raw := json.RawMessage(aux)
+  enc.Encode(jsonmessage.JSONMessage{Aux: &raw})
+  enc.Encode(jsonmessage.JSONMessage{Stream: fmt.Sprintf("Successfully built %s\n", strings.TrimPrefix(id, "sha256:")[:12])})
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  var tags []string
+  for _, t := range query["t"] {
+    t = normalizeName(t)
+    s.untag(t)
+    tags = append(tags, t)
+    enc.Encode(jsonmessage.JSONMessage{Stream: fmt.Sprintf("Successfully tagged %s\n", t)})
+  }
+  if e := s.find(id); e != nil {
+    e.RepoTags = append(e.RepoTags, tags...)
+    return
+  }
+  s.engines = append(s.engines, &types.EngineInspect{
+    ID:         id,
+    RepoTags:   tags,
+    Enginefile: query.Get("enginefile"),
+    BuildArgs:  buildArgs,
+    Created:    time.Now().UTC().Format(time.RFC3339Nano),
+  })
+}
+
+// usesBuildArg reports whether a build step declares the build arg name.
+func (s *Server) usesBuildArg(name string) bool {
+  for _, step := range s.BuildSteps {
+    fields := strings.Fields(step)
+    if len(fields) == 2 && strings.EqualFold(fields[0], "ARG") && strings.SplitN(fields[1], "=", 2)[0] == name {
+      return true
+    }
+  }
+  return false
+}
+
+// normalizeName adds the default tag to a name without tag.
+func normalizeName(name string) string {
+  if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+    return name + ":latest"
+  }
+  return name
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+  w.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(statusCode)
+  json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
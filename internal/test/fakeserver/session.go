@@ -0,0 +1,104 @@
+package fakeserver
+
+import (
+  "archive/tar"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "net/http"
+  "path"
+  "strings"
+
+  "github.com/TopPano/providence-cli/api/types"
+)
+
+// contextSession opens a context session and returns the hashes the
+// server doesn't hold yet.
+func (s *Server) contextSession(w http.ResponseWriter, r *http.Request) {
+  var request types.ContextSessionRequest
+  if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+    writeError(w, http.StatusBadRequest, err.Error())
+    return
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.sessionCount++
+  id := fmt.Sprintf("session-%d", s.sessionCount)
+  s.sessions[id] = true
+
+  response := types.ContextSessionResponse{ID: id, Missing: []string{}}
+  for _, hash := range request.Hashes {
+    if _, ok := s.blobs[hash]; !ok {
+      response.Missing = append(response.Missing, hash)
+    }
+  }
+  writeJSON(w, response)
+}
+
+// readSessionContext reads the build context uploaded for the context
+// session id, stores its blobs and returns its manifest. Every file of the
+// manifest must be known once the blobs are stored.
+func (s *Server) readSessionContext(id string, body io.Reader) ([]byte, error) {
+  s.mu.Lock()
+  ok := s.sessions[id]
+  delete(s.sessions, id)
+  s.mu.Unlock()
+  if !ok {
+    return nil, fmt.Errorf("unknown context session %q", id)
+  }
+
+  body, err := decompress(body)
+  if err != nil {
+    return nil, err
+  }
+
+  var manifestBytes []byte
+  blobs := map[string][]byte{}
+  tr := tar.NewReader(body)
+  for {
+    hdr, err := tr.Next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return nil, err
+    }
+    content, err := ioutil.ReadAll(tr)
+    if err != nil {
+      return nil, err
+    }
+    if hdr.Name == types.ContextManifestPath {
+      manifestBytes = content
+      continue
+    }
+    hash := strings.Replace(strings.TrimPrefix(hdr.Name, types.ContextBlobsDir+"/"), "/", ":", 1)
+    sum := sha256.Sum256(content)
+    if path.Dir(hdr.Name) != types.ContextBlobsDir+"/sha256" || hash != "sha256:"+hex.EncodeToString(sum[:]) {
+      return nil, fmt.Errorf("invalid blob %s", hdr.Name)
+    }
+    blobs[hash] = content
+  }
+  if manifestBytes == nil {
+    return nil, fmt.Errorf("missing %s", types.ContextManifestPath)
+  }
+  var manifest types.ContextManifest
+  if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+    return nil, err
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  for hash, content := range blobs {
+    s.blobs[hash] = content
+  }
+  for _, entry := range manifest.Entries {
+    if _, ok := s.blobs[entry.Hash]; entry.Hash != "" && !ok {
+      return nil, fmt.Errorf("missing content of %s", entry.Path)
+    }
+  }
+  return manifestBytes, nil
+}
@@ -0,0 +1,44 @@
+// Package golden compares the output of tests with golden files stored in
+// the testdata directory of the package under test. Run the tests with
+// -test.update-golden to write the current output to the golden files.
+package golden
+
+import (
+  "flag"
+  "io/ioutil"
+  "path/filepath"
+  "testing"
+)
+
+var update = flag.Bool("test.update-golden", false, "update golden files")
+
+// Path returns the path of the golden file with the given name.
+func Path(filename string) string {
+  return filepath.Join("testdata", filename)
+}
+
+// Get returns the content of the golden file with the given name.
+func Get(t *testing.T, filename string) []byte {
+  expected, err := ioutil.ReadFile(Path(filename))
+  if err != nil {
+    t.Fatalf("unable to read golden file: %v", err)
+  }
+  return expected
+}
+
+// Assert fails the test if actual differs from the content of the golden
+// file with the given name. The golden file is overwritten instead when
+// the tests run with -test.update-golden.
+func Assert(t *testing.T, actual, filename string) {
+  if *update {
+    if err := ioutil.WriteFile(Path(filename), []byte(actual), 0644); err != nil {
+      t.Fatalf("unable to update golden file: %v", err)
+    }
+    return
+  }
+
+  expected := string(Get(t, filename))
+  if actual != expected {
+    t.Fatalf("output does not match %s\n--- expected\n%s\n--- actual\n%s", Path(filename), expected, actual)
+  }
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+  "errors"
   "fmt"
   "os"
+  "strings"
+
+  "golang.org/x/net/context"
 
   "github.com/Sirupsen/logrus"
+  "github.com/TopPano/providence-cli/api/types/versions"
   "github.com/TopPano/providence-cli/cli"
   "github.com/TopPano/providence-cli/cli/command"
   "github.com/TopPano/providence-cli/cli/command/commands"
+  cliconfig "github.com/TopPano/providence-cli/cli/config"
   cliflags "github.com/TopPano/providence-cli/cli/flags"
+  "github.com/TopPano/providence-cli/client"
   "github.com/docker/docker/pkg/term"
   "github.com/dnephin/cobra"
   "github.com/spf13/pflag"
@@ -37,12 +44,16 @@ func newProvidenceCommand(provCli *command.ProvCli) *cobra.Command {
       // flags must be the top-level command flags, not cmd.Flags()
       opts.Common.SetDefaultOptions(flags)
       provPreRun(opts)
-      return provCli.Initialize(opts)
+      if err := provCli.Initialize(opts); err != nil {
+        return err
+      }
+      return isSupported(cmd, provCli.Client())
     },
   }
   cli.SetupRootCommand(cmd)
 
   flags = cmd.Flags()
+  flags.StringVar(&opts.ConfigDir, "config", cliconfig.Dir(), "Location of client config files")
   flags.BoolVarP(&opts.Version, "version", "v", false, "Print version information and quit")
   opts.Common.InstallFlags(flags)
 
@@ -69,26 +80,67 @@ func main() {
   cmd := newProvidenceCommand(provCli)
 
   if err := cmd.Execute(); err != nil {
-    if sterr, ok := err.(cli.StatusError); ok {
-      if sterr.Status != "" {
-        fmt.Fprintln(stderr, sterr.Status)
-      }
-      // StatusError should only be used for errors, and all errors should
-      // have a non-zero exit status, so never exit with 0
-      if sterr.StatusCode == 0 {
-        os.Exit(1)
-      }
-      os.Exit(sterr.StatusCode)
+    sterr := cli.ToStatusError(err)
+    if sterr.Status != "" {
+      fmt.Fprintln(stderr, sterr.Status)
+    }
+    // StatusError should only be used for errors, and all errors should
+    // have a non-zero exit status, so never exit with 0
+    if sterr.StatusCode == 0 {
+      os.Exit(1)
     }
-    fmt.Fprintln(stderr, err)
-    os.Exit(1)
+    os.Exit(sterr.StatusCode)
   }
 }
 
 func showVersion() {
-  fmt.Printf("Providence version 1.0")
+  fmt.Printf("Providence version %s, build %s\n", cli.Version, cli.GitCommit)
 }
 
 func provPreRun(opts *cliflags.ClientOptions) {
   cliflags.SetLogLevel(opts.Common.LogLevel)
+
+  if opts.ConfigDir != "" {
+    cliconfig.SetDir(opts.ConfigDir)
+  }
+}
+
+// isSupported returns an error if the command or any of the flags set on it
+// require a newer API version than the one negotiated with the server. The
+// server is only contacted when such a version is required.
+func isSupported(cmd *cobra.Command, apiClient client.APIClient) error {
+  versionedFlags := []*pflag.Flag{}
+  cmd.Flags().VisitAll(func(f *pflag.Flag) {
+    if f.Changed && getFlagVersion(f) != "" {
+      versionedFlags = append(versionedFlags, f)
+    }
+  })
+  cmdVersion, ok := cmd.Tags["version"]
+  if !ok && len(versionedFlags) == 0 {
+    return nil
+  }
+
+  apiClient.NegotiateAPIVersion(context.Background())
+  clientVersion := apiClient.ClientVersion()
+  if ok && versions.LessThan(clientVersion, cmdVersion) {
+    return fmt.Errorf("%s requires API version %s, but the Providence server API version is %s", cmd.CommandPath(), cmdVersion, clientVersion)
+  }
+
+  errs := []string{}
+  for _, f := range versionedFlags {
+    if flagVersion := getFlagVersion(f); versions.LessThan(clientVersion, flagVersion) {
+      errs = append(errs, fmt.Sprintf("\"--%s\" requires API version %s, but the Providence server API version is %s", f.Name, flagVersion, clientVersion))
+    }
+  }
+  if len(errs) > 0 {
+    return errors.New(strings.Join(errs, "\n"))
+  }
+  return nil
+}
+
+func getFlagVersion(f *pflag.Flag) string {
+  if flagVersion, ok := f.Annotations["version"]; ok && len(flagVersion) == 1 {
+    return flagVersion[0]
+  }
+  return ""
 }
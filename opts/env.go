@@ -0,0 +1,46 @@
+package opts
+
+import (
+  "fmt"
+  "os"
+  "strings"
+)
+
+// ValidateEnv validates an environment variable and returns it.
+// If no value is specified, it obtains its value from the current environment.
+// A variable that is not set in the environment is returned as a bare name.
+//
+// Variable names are not validated beyond checking that they are not empty,
+// it's up to the Enginefile to decide what it accepts.
+func ValidateEnv(val string) (string, error) {
+  arr := strings.Split(val, "=")
+  if arr[0] == "" {
+    return "", fmt.Errorf("invalid environment variable: %s", val)
+  }
+  if len(arr) > 1 {
+    return val, nil
+  }
+  if value, ok := os.LookupEnv(val); ok {
+    return fmt.Sprintf("%s=%s", val, value), nil
+  }
+  return val, nil
+}
+
+// ConvertKVStringsToMapWithNil converts ["key=value"] to {"key":"value"}
+// but set unset keys to nil - meaning the ones with no "=" in them.
+// We use this in cases where we need to distinguish between
+//   FOO=  and FOO
+// where the latter case just means FOO was mentioned but not given a value
+func ConvertKVStringsToMapWithNil(values []string) map[string]*string {
+  result := make(map[string]*string, len(values))
+  for _, value := range values {
+    kv := strings.SplitN(value, "=", 2)
+    if len(kv) == 1 {
+      result[kv[0]] = nil
+    } else {
+      result[kv[0]] = &kv[1]
+    }
+  }
+
+  return result
+}
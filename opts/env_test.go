@@ -0,0 +1,50 @@
+package opts
+
+import (
+  "os"
+  "testing"
+)
+
+func TestValidateEnv(t *testing.T) {
+  os.Setenv("PROV_OPTS_TEST_VAR", "from-env")
+  defer os.Unsetenv("PROV_OPTS_TEST_VAR")
+  os.Unsetenv("PROV_OPTS_TEST_UNSET")
+
+  valids := map[string]string{
+    "a=b":                  "a=b",
+    "a=":                   "a=",
+    "a=b=c":                "a=b=c",
+    "PROV_OPTS_TEST_VAR":   "PROV_OPTS_TEST_VAR=from-env",
+    "PROV_OPTS_TEST_UNSET": "PROV_OPTS_TEST_UNSET",
+  }
+  for value, expected := range valids {
+    actual, err := ValidateEnv(value)
+    if err != nil {
+      t.Fatalf("ValidateEnv(%q) returned an error: %v", value, err)
+    }
+    if actual != expected {
+      t.Fatalf("ValidateEnv(%q): expected %q, got %q", value, expected, actual)
+    }
+  }
+
+  if _, err := ValidateEnv("=a"); err == nil {
+    t.Fatal("Expected an error for an empty variable name")
+  }
+}
+
+func TestConvertKVStringsToMapWithNil(t *testing.T) {
+  result := ConvertKVStringsToMapWithNil([]string{"FOO=bar", "EMPTY=", "UNSET"})
+
+  if len(result) != 3 {
+    t.Fatalf("Expected 3 entries, got %v", result)
+  }
+  if v := result["FOO"]; v == nil || *v != "bar" {
+    t.Fatalf("Expected FOO to be bar, got %v", v)
+  }
+  if v := result["EMPTY"]; v == nil || *v != "" {
+    t.Fatalf("Expected EMPTY to be an empty string, got %v", v)
+  }
+  if v, ok := result["UNSET"]; !ok || v != nil {
+    t.Fatalf("Expected UNSET to be present with a nil value, got %v", v)
+  }
+}
@@ -0,0 +1,41 @@
+package opts
+
+import (
+  "fmt"
+  "net/url"
+  "path/filepath"
+  "strings"
+)
+
+// ValidateHost validates that the specified string is a valid Providence
+// host and returns it. Hosts must name their protocol: tcp://, http:// and
+// https:// hosts need an address, unix:// hosts need an absolute socket path.
+func ValidateHost(val string) (string, error) {
+  host := strings.TrimSpace(val)
+  protoAddrParts := strings.SplitN(host, "://", 2)
+  if len(protoAddrParts) == 1 {
+    return "", fmt.Errorf("Invalid host %q: missing protocol, expected tcp://, http://, https:// or unix://", val)
+  }
+
+  proto, addr := protoAddrParts[0], protoAddrParts[1]
+  switch proto {
+  case "tcp", "http", "https":
+    parsed, err := url.Parse(host)
+    if err != nil {
+      return "", fmt.Errorf("Invalid host %q: %v", val, err)
+    }
+    if parsed.Host == "" {
+      return "", fmt.Errorf("Invalid host %q: missing address", val)
+    }
+  case "unix":
+    if addr == "" {
+      return "", fmt.Errorf("Invalid host %q: missing socket path", val)
+    }
+    if !filepath.IsAbs(addr) {
+      return "", fmt.Errorf("Invalid host %q: socket path must be absolute", val)
+    }
+  default:
+    return "", fmt.Errorf("Invalid host %q: unsupported protocol %q", val, proto)
+  }
+  return host, nil
+}
@@ -0,0 +1,36 @@
+package opts
+
+import (
+  "testing"
+)
+
+func TestValidateHost(t *testing.T) {
+  valids := map[string]string{
+    "tcp://localhost:8080":             "tcp://localhost:8080",
+    " http://providence.example.com ":  "http://providence.example.com",
+    "https://providence.example.com/v": "https://providence.example.com/v",
+    "unix:///var/run/providence.sock":  "unix:///var/run/providence.sock",
+  }
+  for value, expected := range valids {
+    actual, err := ValidateHost(value)
+    if err != nil {
+      t.Fatalf("ValidateHost(%q) returned an error: %v", value, err)
+    }
+    if actual != expected {
+      t.Fatalf("ValidateHost(%q): expected %q, got %q", value, expected, actual)
+    }
+  }
+
+  invalids := []string{
+    "localhost:8080",
+    "tcp://",
+    "unix://",
+    "unix://relative/providence.sock",
+    "udp://localhost:8080",
+  }
+  for _, value := range invalids {
+    if _, err := ValidateHost(value); err == nil {
+      t.Fatalf("Expected ValidateHost(%q) to fail", value)
+    }
+  }
+}
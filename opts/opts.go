@@ -0,0 +1,138 @@
+// Package opts defines custom flag types shared by the prov commands.
+package opts
+
+import (
+  "fmt"
+
+  "github.com/TopPano/providence-cli/api/types/filters"
+)
+
+// ListOpts holds a list of values and a validation function.
+type ListOpts struct {
+  values    *[]string
+  validator ValidatorFctType
+}
+
+// NewListOpts creates a new ListOpts with the specified validator.
+func NewListOpts(validator ValidatorFctType) ListOpts {
+  var values []string
+  return *NewListOptsRef(&values, validator)
+}
+
+// NewListOptsRef creates a new ListOpts with the specified values and validator.
+func NewListOptsRef(values *[]string, validator ValidatorFctType) *ListOpts {
+  return &ListOpts{
+    values:    values,
+    validator: validator,
+  }
+}
+
+func (opts *ListOpts) String() string {
+  return fmt.Sprintf("%v", []string((*opts.values)))
+}
+
+// Set validates if needed the input value and adds it to the
+// internal slice.
+func (opts *ListOpts) Set(value string) error {
+  if opts.validator != nil {
+    v, err := opts.validator(value)
+    if err != nil {
+      return err
+    }
+    value = v
+  }
+  (*opts.values) = append((*opts.values), value)
+  return nil
+}
+
+// GetAll returns the values of slice.
+func (opts *ListOpts) GetAll() []string {
+  return (*opts.values)
+}
+
+// GetAllOrEmpty returns the values of the slice
+// or an empty slice when there are no values.
+func (opts *ListOpts) GetAllOrEmpty() []string {
+  v := *opts.values
+  if v == nil {
+    return make([]string, 0)
+  }
+  return v
+}
+
+// Len returns the amount of element in the slice.
+func (opts *ListOpts) Len() int {
+  return len((*opts.values))
+}
+
+// Type returns a string name for this Option type
+func (opts *ListOpts) Type() string {
+  return "list"
+}
+
+// NamedOption is an interface that list and map options
+// with names implement.
+type NamedOption interface {
+  Name() string
+}
+
+// NamedListOpts is a ListOpts with a configuration name.
+// This struct is useful to keep reference to the assigned
+// field name in the internal configuration struct.
+type NamedListOpts struct {
+  name string
+  ListOpts
+}
+
+var _ NamedOption = &NamedListOpts{}
+
+// NewNamedListOptsRef creates a reference to a new NamedListOpts struct.
+func NewNamedListOptsRef(name string, values *[]string, validator ValidatorFctType) *NamedListOpts {
+  return &NamedListOpts{
+    name:     name,
+    ListOpts: *NewListOptsRef(values, validator),
+  }
+}
+
+// Name returns the name of the NamedListOpts in the configuration.
+func (o *NamedListOpts) Name() string {
+  return o.name
+}
+
+// ValidatorFctType defines a validator function that returns a validated string and/or an error.
+type ValidatorFctType func(val string) (string, error)
+
+// FilterOpt is a flag type for validating filters
+type FilterOpt struct {
+  filter filters.Args
+}
+
+// NewFilterOpt returns a new FilterOpt
+func NewFilterOpt() FilterOpt {
+  return FilterOpt{filter: filters.NewArgs()}
+}
+
+func (o *FilterOpt) String() string {
+  repr, err := filters.ToParam(o.filter)
+  if err != nil {
+    return "invalid filters"
+  }
+  return repr
+}
+
+// Set sets the value of the opt by parsing the command line value
+func (o *FilterOpt) Set(value string) error {
+  var err error
+  o.filter, err = filters.ParseFlag(value, o.filter)
+  return err
+}
+
+// Type returns the option type
+func (o *FilterOpt) Type() string {
+  return "filter"
+}
+
+// Value returns the value of this option
+func (o *FilterOpt) Value() filters.Args {
+  return o.filter
+}
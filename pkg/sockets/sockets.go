@@ -0,0 +1,47 @@
+// Package sockets provides helper functions to create and configure the
+// transports used to reach a Providence server.
+package sockets
+
+import (
+  "context"
+  "fmt"
+  "net"
+  "net/http"
+  "time"
+)
+
+// defaultTimeout is the time allowed to establish a connection.
+const defaultTimeout = 32 * time.Second
+
+// maxUnixSocketPathSize is the size of sun_path in struct sockaddr_un
+// on Linux, minus the trailing NUL byte.
+const maxUnixSocketPathSize = 107
+
+// ConfigureTransport configures the specified Transport according to the
+// specified proto and addr.
+// If the proto is unix (using a unix socket to communicate) the compression
+// is disabled.
+func ConfigureTransport(tr *http.Transport, proto, addr string) error {
+  switch proto {
+  case "unix":
+    return configureUnixTransport(tr, proto, addr)
+  default:
+    tr.Proxy = http.ProxyFromEnvironment
+    dialer := &net.Dialer{Timeout: defaultTimeout}
+    tr.DialContext = dialer.DialContext
+  }
+  return nil
+}
+
+func configureUnixTransport(tr *http.Transport, proto, addr string) error {
+  if len(addr) > maxUnixSocketPathSize {
+    return fmt.Errorf("Unix socket path %q is too long", addr)
+  }
+  // No need for compression in local communications.
+  tr.DisableCompression = true
+  dialer := &net.Dialer{Timeout: defaultTimeout}
+  tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+    return dialer.DialContext(ctx, proto, addr)
+  }
+  return nil
+}
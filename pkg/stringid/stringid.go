@@ -0,0 +1,20 @@
+// Package stringid provides helper functions for dealing with string identifiers
+package stringid
+
+import (
+  "strings"
+)
+
+const shortLen = 12
+
+// TruncateID returns a shorthand version of a string identifier for convenience.
+// The algorithm prefix (e.g. "sha256:") is dropped before truncating.
+func TruncateID(id string) string {
+  if i := strings.IndexRune(id, ':'); i >= 0 {
+    id = id[i+1:]
+  }
+  if len(id) > shortLen {
+    id = id[:shortLen]
+  }
+  return id
+}
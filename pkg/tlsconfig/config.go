@@ -0,0 +1,85 @@
+// Package tlsconfig provides primitives to retrieve secure-enough TLS
+// configurations for clients talking to a Providence server.
+package tlsconfig
+
+import (
+  "crypto/tls"
+  "crypto/x509"
+  "fmt"
+  "io/ioutil"
+  "os"
+)
+
+// Options represents the information needed to create client TLS configurations.
+type Options struct {
+  CAFile string
+
+  // If either CertFile or KeyFile is empty, Client() will not load them
+  // preventing the client from authenticating to the server.
+  CertFile string
+  KeyFile  string
+
+  // InsecureSkipVerify disables the verification of the server certificate.
+  InsecureSkipVerify bool
+  // MinVersion is the minimum TLS version to accept. Defaults to
+  // DefaultMinVersion when left empty.
+  MinVersion uint16
+}
+
+// DefaultMinVersion is the minimum TLS version the client negotiates unless
+// configured otherwise.
+const DefaultMinVersion = tls.VersionTLS12
+
+// ClientDefault returns a secure-enough TLS configuration for the client.
+func ClientDefault() *tls.Config {
+  return &tls.Config{
+    MinVersion: DefaultMinVersion,
+  }
+}
+
+// certPool returns an X.509 certificate pool from `caFile`, the certificate file.
+// Only the certificates in caFile are trusted, the system roots are not added.
+func certPool(caFile string) (*x509.CertPool, error) {
+  certPool := x509.NewCertPool()
+  pem, err := ioutil.ReadFile(caFile)
+  if err != nil {
+    return nil, fmt.Errorf("Could not read CA certificate %q: %v", caFile, err)
+  }
+  if !certPool.AppendCertsFromPEM(pem) {
+    return nil, fmt.Errorf("failed to append certificates from PEM file: %q", caFile)
+  }
+  return certPool, nil
+}
+
+// Client returns a TLS configuration meant to be used by a client.
+func Client(options Options) (*tls.Config, error) {
+  tlsConfig := ClientDefault()
+  tlsConfig.InsecureSkipVerify = options.InsecureSkipVerify
+  if options.MinVersion != 0 {
+    if options.MinVersion < DefaultMinVersion {
+      return nil, fmt.Errorf("invalid minimum TLS version: it must be at least TLS 1.2")
+    }
+    tlsConfig.MinVersion = options.MinVersion
+  }
+
+  if !options.InsecureSkipVerify && options.CAFile != "" {
+    CAs, err := certPool(options.CAFile)
+    if err != nil {
+      return nil, err
+    }
+    tlsConfig.RootCAs = CAs
+  }
+
+  if options.CertFile != "" || options.KeyFile != "" {
+    tlsCert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+    if err != nil {
+      if os.IsNotExist(err) {
+        return nil, fmt.Errorf("Could not load X509 key pair (cert: %q, key: %q): %v", options.CertFile, options.KeyFile, err)
+      }
+      return nil, fmt.Errorf("Could not load X509 key pair: %v", err)
+    }
+    tlsConfig.Certificates = []tls.Certificate{tlsCert}
+  }
+
+  return tlsConfig, nil
+}
@@ -0,0 +1,51 @@
+package tlsconfig
+
+import (
+  "crypto/tls"
+  "path/filepath"
+  "testing"
+)
+
+func TestClientDefaultMinVersion(t *testing.T) {
+  config, err := Client(Options{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if config.MinVersion != DefaultMinVersion {
+    t.Fatalf("Expected MinVersion %x, got %x", DefaultMinVersion, config.MinVersion)
+  }
+  if config.RootCAs != nil {
+    t.Fatal("Expected the system roots to be used when no CA file is given")
+  }
+}
+
+func TestClientRejectsOldTLSVersions(t *testing.T) {
+  if _, err := Client(Options{MinVersion: tls.VersionTLS10}); err == nil {
+    t.Fatal("Expected an error for a minimum version below TLS 1.2")
+  }
+
+  config, err := Client(Options{MinVersion: tls.VersionTLS13})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if config.MinVersion != tls.VersionTLS13 {
+    t.Fatalf("Expected MinVersion to be TLS 1.3, got %x", config.MinVersion)
+  }
+}
+
+func TestClientMissingFiles(t *testing.T) {
+  dir := t.TempDir()
+
+  if _, err := Client(Options{CAFile: filepath.Join(dir, "ca.pem")}); err == nil {
+    t.Fatal("Expected an error for a missing CA file")
+  }
+
+  // The CA file is not read when verification is disabled.
+  if _, err := Client(Options{CAFile: filepath.Join(dir, "ca.pem"), InsecureSkipVerify: true}); err != nil {
+    t.Fatalf("Expected no error when skipping verification, got %v", err)
+  }
+
+  if _, err := Client(Options{CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}); err == nil {
+    t.Fatal("Expected an error for a missing client certificate")
+  }
+}
@@ -0,0 +1,363 @@
+// Package reference provides a general type to represent any way of
+// referencing an engine on a Providence host.
+//
+// Grammar
+//
+//   reference                       := name [ ":" tag ] [ "@" digest ]
+//   name                            := [hostname '/'] component ['/' component]*
+//   hostname                        := hostcomponent ['.' hostcomponent]* [':' port-number]
+//   hostcomponent                   := /([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])/
+//   port-number                     := /[0-9]+/
+//   component                       := alpha-numeric [separator alpha-numeric]*
+//   alpha-numeric                   := /[a-z0-9]+/
+//   separator                       := /[_.]|__|[-]*/
+//
+//   tag                             := /[\w][\w.-]{0,127}/
+//
+//   digest                          := digest-algorithm ":" digest-hex
+//   digest-algorithm                := digest-algorithm-component [ digest-algorithm-separator digest-algorithm-component ]
+//   digest-algorithm-separator      := /[+.-_]/
+//   digest-algorithm-component      := /[A-Za-z][A-Za-z0-9]*/
+//   digest-hex                      := /[0-9a-fA-F]{32,}/ ; At least 128 bit digest value
+package reference
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+)
+
+const (
+  // NameTotalLengthMax is the maximum total number of characters in a repository name.
+  NameTotalLengthMax = 255
+
+  // DefaultTag defines the default tag used when performing engine operations
+  DefaultTag = "latest"
+)
+
+var (
+  // ErrReferenceInvalidFormat represents an error while trying to parse a string as a reference.
+  ErrReferenceInvalidFormat = errors.New("invalid reference format")
+
+  // ErrTagInvalidFormat represents an error while trying to parse a string as a tag.
+  ErrTagInvalidFormat = errors.New("invalid tag format")
+
+  // ErrDigestInvalidFormat represents an error while trying to parse a string as a digest.
+  ErrDigestInvalidFormat = errors.New("invalid digest format")
+
+  // ErrNameContainsUppercase is returned for invalid repository names that contain uppercase characters.
+  ErrNameContainsUppercase = errors.New("repository name must be lowercase")
+
+  // ErrNameEmpty is returned for empty, invalid repository names.
+  ErrNameEmpty = errors.New("repository name must have at least one component")
+
+  // ErrNameTooLong is returned when a repository name is longer than NameTotalLengthMax.
+  ErrNameTooLong = fmt.Errorf("repository name must not be more than %v characters", NameTotalLengthMax)
+)
+
+// Reference is an opaque object reference identifier that may include
+// modifiers such as a hostname, name, tag, and digest.
+type Reference interface {
+  // String returns the full reference
+  String() string
+}
+
+// Named is an object with a full name
+type Named interface {
+  Reference
+  Name() string
+}
+
+// Tagged is an object which has a tag
+type Tagged interface {
+  Reference
+  Tag() string
+}
+
+// NamedTagged is an object including a name and tag.
+type NamedTagged interface {
+  Named
+  Tag() string
+}
+
+// Digested is an object which has a digest
+// in which it can be referenced by
+type Digested interface {
+  Reference
+  Digest() string
+}
+
+// Canonical reference is an object with a fully unique
+// name including a name with hostname and digest
+type Canonical interface {
+  Named
+  Digest() string
+}
+
+// SplitHostname splits a named reference into a
+// hostname and name string. If no valid hostname is
+// found, the hostname is empty and the full value
+// is returned as name
+func SplitHostname(named Named) (string, string) {
+  name := named.Name()
+  match := anchoredNameRegexp.FindStringSubmatch(name)
+  if len(match) != 3 {
+    return "", name
+  }
+  return match[1], match[2]
+}
+
+// Parse parses s and returns a syntactically valid Reference.
+// If an error was encountered it is returned, along with a nil Reference.
+func Parse(s string) (Reference, error) {
+  matches := ReferenceRegexp.FindStringSubmatch(s)
+  if matches == nil {
+    if s == "" {
+      return nil, ErrNameEmpty
+    }
+    if ReferenceRegexp.FindStringSubmatch(strings.ToLower(s)) != nil {
+      return nil, ErrNameContainsUppercase
+    }
+    return nil, ErrReferenceInvalidFormat
+  }
+
+  if len(matches[1]) > NameTotalLengthMax {
+    return nil, ErrNameTooLong
+  }
+
+  ref := reference{
+    name: matches[1],
+    tag:  matches[2],
+  }
+  if matches[3] != "" {
+    if err := validateDigest(matches[3]); err != nil {
+      return nil, err
+    }
+    ref.digest = matches[3]
+  }
+
+  r := getBestReferenceType(ref)
+  if r == nil {
+    return nil, ErrNameEmpty
+  }
+
+  return r, nil
+}
+
+// ParseNamed parses s and returns a syntactically valid reference implementing
+// the Named interface. The reference must have a name, otherwise an error is
+// returned.
+// If an error was encountered it is returned, along with a nil Reference.
+func ParseNamed(s string) (Named, error) {
+  ref, err := Parse(s)
+  if err != nil {
+    return nil, err
+  }
+  named, isNamed := ref.(Named)
+  if !isNamed {
+    return nil, fmt.Errorf("reference %s has no name", ref.String())
+  }
+  return named, nil
+}
+
+// WithName returns a named object representing the given string. If the input
+// is invalid ErrReferenceInvalidFormat will be returned.
+func WithName(name string) (Named, error) {
+  if len(name) > NameTotalLengthMax {
+    return nil, ErrNameTooLong
+  }
+  if !anchoredNameRegexp.MatchString(name) {
+    return nil, ErrReferenceInvalidFormat
+  }
+  return repository(name), nil
+}
+
+// WithTag combines the name from "name" and the tag from "tag" to form a
+// reference incorporating both the name and the tag.
+func WithTag(name Named, tag string) (NamedTagged, error) {
+  if !anchoredTagRegexp.MatchString(tag) {
+    return nil, ErrTagInvalidFormat
+  }
+  if canonical, ok := name.(Canonical); ok {
+    return reference{
+      name:   name.Name(),
+      tag:    tag,
+      digest: canonical.Digest(),
+    }, nil
+  }
+  return taggedReference{
+    name: name.Name(),
+    tag:  tag,
+  }, nil
+}
+
+// WithDigest combines the name from "name" and the digest from "digest" to form
+// a reference incorporating both the name and the digest.
+func WithDigest(name Named, digest string) (Canonical, error) {
+  if err := validateDigest(digest); err != nil {
+    return nil, err
+  }
+  if tagged, ok := name.(Tagged); ok {
+    return reference{
+      name:   name.Name(),
+      tag:    tagged.Tag(),
+      digest: digest,
+    }, nil
+  }
+  return canonicalReference{
+    name:   name.Name(),
+    digest: digest,
+  }, nil
+}
+
+// IsNameOnly returns true if reference only contains a repo name.
+func IsNameOnly(ref Named) bool {
+  if _, ok := ref.(NamedTagged); ok {
+    return false
+  }
+  if _, ok := ref.(Canonical); ok {
+    return false
+  }
+  return true
+}
+
+// TagNameOnly adds the default tag "latest" to a reference if it only has
+// a repo name.
+func TagNameOnly(ref Named) Named {
+  if IsNameOnly(ref) {
+    namedTagged, err := WithTag(ref, DefaultTag)
+    if err != nil {
+      // Default tag must be valid, to create a NamedTagged
+      // type with non-validated input the WithTag function
+      // should be used instead
+      panic(err)
+    }
+    return namedTagged
+  }
+  return ref
+}
+
+// digestHexLengths holds the length of the hex-encoded value for each
+// supported digest algorithm.
+var digestHexLengths = map[string]int{
+  "sha256": 64,
+  "sha384": 96,
+  "sha512": 128,
+}
+
+// validateDigest checks that digest is well formed and uses a supported
+// algorithm with a value of the right length.
+func validateDigest(digest string) error {
+  if !anchoredDigestRegexp.MatchString(digest) {
+    return ErrDigestInvalidFormat
+  }
+  i := strings.Index(digest, ":")
+  length, ok := digestHexLengths[digest[:i]]
+  if !ok || len(digest[i+1:]) != length {
+    return ErrDigestInvalidFormat
+  }
+  return nil
+}
+
+func getBestReferenceType(ref reference) Reference {
+  if ref.name == "" {
+    // Allow digest only references
+    if ref.digest != "" {
+      return digestReference(ref.digest)
+    }
+    return nil
+  }
+  if ref.tag == "" {
+    if ref.digest != "" {
+      return canonicalReference{
+        name:   ref.name,
+        digest: ref.digest,
+      }
+    }
+    return repository(ref.name)
+  }
+  if ref.digest == "" {
+    return taggedReference{
+      name: ref.name,
+      tag:  ref.tag,
+    }
+  }
+
+  return ref
+}
+
+type reference struct {
+  name   string
+  tag    string
+  digest string
+}
+
+func (r reference) String() string {
+  return r.Name() + ":" + r.tag + "@" + r.digest
+}
+
+func (r reference) Name() string {
+  return r.name
+}
+
+func (r reference) Tag() string {
+  return r.tag
+}
+
+func (r reference) Digest() string {
+  return r.digest
+}
+
+type repository string
+
+func (r repository) String() string {
+  return string(r)
+}
+
+func (r repository) Name() string {
+  return string(r)
+}
+
+type digestReference string
+
+func (d digestReference) String() string {
+  return string(d)
+}
+
+func (d digestReference) Digest() string {
+  return string(d)
+}
+
+type taggedReference struct {
+  name string
+  tag  string
+}
+
+func (t taggedReference) String() string {
+  return t.Name() + ":" + t.tag
+}
+
+func (t taggedReference) Name() string {
+  return t.name
+}
+
+func (t taggedReference) Tag() string {
+  return t.tag
+}
+
+type canonicalReference struct {
+  name   string
+  digest string
+}
+
+func (c canonicalReference) String() string {
+  return c.Name() + "@" + c.digest
+}
+
+func (c canonicalReference) Name() string {
+  return c.name
+}
+
+func (c canonicalReference) Digest() string {
+  return c.digest
+}
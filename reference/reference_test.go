@@ -0,0 +1,154 @@
+package reference
+
+import (
+  "strings"
+  "testing"
+)
+
+func TestReferenceParse(t *testing.T) {
+  testcases := []struct {
+    input      string
+    err        error
+    repository string
+    hostname   string
+    tag        string
+    digest     string
+  }{
+    {
+      input:      "test_com",
+      repository: "test_com",
+    },
+    {
+      input:      "test.com:tag",
+      repository: "test.com",
+      tag:        "tag",
+    },
+    {
+      input:      "test.com:5000",
+      repository: "test.com",
+      tag:        "5000",
+    },
+    {
+      input:      "test.com/repo:tag",
+      hostname:   "test.com",
+      repository: "test.com/repo",
+      tag:        "tag",
+    },
+    {
+      input:      "test:5000/repo",
+      hostname:   "test:5000",
+      repository: "test:5000/repo",
+    },
+    {
+      input:      "test:5000/repo@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+      hostname:   "test:5000",
+      repository: "test:5000/repo",
+      digest:     "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+    },
+    {
+      input:      "test:5000/repo:tag@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+      hostname:   "test:5000",
+      repository: "test:5000/repo",
+      tag:        "tag",
+      digest:     "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+    },
+    {
+      input: "",
+      err:   ErrNameEmpty,
+    },
+    {
+      input: ":justtag",
+      err:   ErrReferenceInvalidFormat,
+    },
+    {
+      input: "@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+      err:   ErrReferenceInvalidFormat,
+    },
+    {
+      input: "repo@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+      err:   ErrDigestInvalidFormat,
+    },
+    {
+      input: "validname@invaliddigest:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+      err:   ErrDigestInvalidFormat,
+    },
+    {
+      input: "Uppercase:tag",
+      err:   ErrNameContainsUppercase,
+    },
+    {
+      input: "a/a/a/a/a/a/b/b/b/b:tag" + strings.Repeat("x", 129),
+      err:   ErrReferenceInvalidFormat,
+    },
+    {
+      input: strings.Repeat("a/", 128) + "a:tag",
+      err:   ErrNameTooLong,
+    },
+  }
+
+  for _, testcase := range testcases {
+    repo, err := Parse(testcase.input)
+    if testcase.err != nil {
+      if err != testcase.err {
+        t.Errorf("%q: expected error %v, got %v", testcase.input, testcase.err, err)
+      }
+      continue
+    }
+    if err != nil {
+      t.Errorf("%q: unexpected parse error %v", testcase.input, err)
+      continue
+    }
+
+    if named, ok := repo.(Named); ok {
+      if named.Name() != testcase.repository {
+        t.Errorf("%q: unexpected repository: got %q, expected %q", testcase.input, named.Name(), testcase.repository)
+      }
+      hostname, _ := SplitHostname(named)
+      if hostname != testcase.hostname {
+        t.Errorf("%q: unexpected hostname: got %q, expected %q", testcase.input, hostname, testcase.hostname)
+      }
+    } else if testcase.repository != "" {
+      t.Errorf("%q: expected named type, got %T", testcase.input, repo)
+    }
+
+    tagged, ok := repo.(Tagged)
+    if testcase.tag != "" {
+      if !ok {
+        t.Errorf("%q: expected tagged type, got %T", testcase.input, repo)
+      } else if tagged.Tag() != testcase.tag {
+        t.Errorf("%q: unexpected tag: got %q, expected %q", testcase.input, tagged.Tag(), testcase.tag)
+      }
+    } else if ok {
+      t.Errorf("%q: unexpected tagged type", testcase.input)
+    }
+
+    digested, ok := repo.(Digested)
+    if testcase.digest != "" {
+      if !ok {
+        t.Errorf("%q: expected digested type, got %T", testcase.input, repo)
+      } else if digested.Digest() != testcase.digest {
+        t.Errorf("%q: unexpected digest: got %q, expected %q", testcase.input, digested.Digest(), testcase.digest)
+      }
+    } else if ok {
+      t.Errorf("%q: unexpected digested type", testcase.input)
+    }
+  }
+}
+
+func TestTagNameOnly(t *testing.T) {
+  named, err := ParseNamed("providence/engine")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if s := TagNameOnly(named).String(); s != "providence/engine:latest" {
+    t.Fatalf("Expected default tag to be added, got %q", s)
+  }
+
+  named, err = ParseNamed("providence/engine:v1")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if s := TagNameOnly(named).String(); s != "providence/engine:v1" {
+    t.Fatalf("Expected tag to be preserved, got %q", s)
+  }
+}